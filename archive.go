@@ -0,0 +1,243 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package trackml
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+)
+
+// Files of an event may be stored plain or gzip- or zstd-compressed, with a
+// .gz or .zst extension.
+// Compression is detected from the magic bytes of the content, whatever the
+// extension of the file.
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	zipMagic  = []byte("PK\x03\x04")
+	tarMagic  = []byte("ustar") // at offset 257 of the first header
+)
+
+// compressExts are the extensions of compressed files, in lookup order.
+var compressExts = []string{".gz", ".zst"}
+
+// tarExts are the extensions of (compressed) tar archives.
+var tarExts = []string{".tar", ".tar.gz", ".tgz", ".tar.zst", ".tzst"}
+
+// trimCompressExt returns name without its compression extension, if any.
+func trimCompressExt(name string) string {
+	for _, ext := range compressExts {
+		if strings.HasSuffix(name, ext) {
+			return strings.TrimSuffix(name, ext)
+		}
+	}
+	return name
+}
+
+// openFile opens the named file of fsys for reading, decompressing its content
+// if needed.
+// If the file does not exist, its compressed variants fname.gz and fname.zst
+// are tried in turn.
+func openFile(fsys fs.FS, fname string) (io.ReadCloser, error) {
+	f, err := fsys.Open(fname)
+	if errors.Is(err, fs.ErrNotExist) {
+		for _, ext := range compressExts {
+			g, gerr := fsys.Open(fname + ext)
+			if !errors.Is(gerr, fs.ErrNotExist) {
+				f, err = g, gerr
+				break
+			}
+		}
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	r, err := decompress(f)
+	if err != nil {
+		f.Close()
+		return nil, errors.Wrapf(err, "could not decompress %q", fname)
+	}
+	return r, nil
+}
+
+// readFile returns the decompressed content of the named file of fsys,
+// located as with openFile.
+func readFile(fsys fs.FS, fname string) ([]byte, error) {
+	r, err := openFile(fsys, fname)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var buf bytes.Buffer
+	if rc, ok := r.(readCloser); ok {
+		if f, ok := rc.src.(fs.File); ok {
+			if fi, err := f.Stat(); err == nil {
+				buf.Grow(int(fi.Size()) + bytes.MinRead)
+			}
+		}
+	}
+	_, err = buf.ReadFrom(r)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return buf.Bytes(), nil
+}
+
+// readCloser is a decompressed reader of src.
+type readCloser struct {
+	io.Reader
+	src   io.ReadCloser
+	close func()
+}
+
+func (rc readCloser) Close() error {
+	if rc.close != nil {
+		rc.close()
+	}
+	return rc.src.Close()
+}
+
+// decompress returns a reader of the decompressed content of src, a gzip- or
+// zstd-compressed or plain stream.
+// Closing the returned reader closes src.
+func decompress(src io.ReadCloser) (io.ReadCloser, error) {
+	br := bufio.NewReader(src)
+	magic, _ := br.Peek(len(zstdMagic))
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not open gzip stream")
+		}
+		return readCloser{Reader: zr, src: src, close: func() { zr.Close() }}, nil
+	case bytes.HasPrefix(magic, zstdMagic):
+		zr, err := zstd.NewReader(br, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not open zstd stream")
+		}
+		return readCloser{Reader: zr, src: src, close: zr.Close}, nil
+	}
+	return readCloser{Reader: br, src: src}, nil
+}
+
+// openArchive opens the named archive f, a zip file or a plain, gzip- or
+// zstd-compressed tar file.
+// It returns a reader of the zip file, or of the decompressed content of the
+// tar file.
+//
+// The format is selected by the extension of the file name or by magic bytes.
+// Zip files that do not implement io.ReaderAt are read in memory.
+func openArchive(f fs.File, name string) (*zip.Reader, io.ReadCloser, error) {
+	br := bufio.NewReader(f)
+	magic, _ := br.Peek(len(zipMagic))
+	if strings.HasSuffix(name, ".zip") || bytes.Equal(magic, zipMagic) {
+		var (
+			ra   io.ReaderAt
+			size int64
+		)
+		switch ff := f.(type) {
+		case io.ReaderAt:
+			fi, err := f.Stat()
+			if err != nil {
+				return nil, nil, errors.WithStack(err)
+			}
+			ra, size = ff, fi.Size()
+		default:
+			raw, err := ioutil.ReadAll(br)
+			if err != nil {
+				return nil, nil, errors.Wrapf(err, "could not read zip archive")
+			}
+			ra, size = bytes.NewReader(raw), int64(len(raw))
+		}
+		zr, err := zip.NewReader(ra, size)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "could not open zip archive")
+		}
+		return zr, nil, nil
+	}
+
+	rc, err := decompress(ioutil.NopCloser(br))
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "could not open archive")
+	}
+
+	tr := bufio.NewReader(rc)
+	hdr, _ := tr.Peek(257 + len(tarMagic))
+	if !bytes.HasSuffix(hdr, tarMagic) && !hasTarExt(name) {
+		rc.Close()
+		return nil, nil, errors.Errorf("unknown archive format (want a zip or tar file)")
+	}
+	return nil, readCloser{Reader: tr, src: rc}, nil
+}
+
+// walkTar calls fct with the name and content of each regular file of the
+// tar stream r.
+func walkTar(r io.Reader, fct func(name string, r io.Reader) error) error {
+	tr := tar.NewReader(r)
+	for {
+		th, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrapf(err, "could not read tar archive")
+		}
+		if !th.FileInfo().Mode().IsRegular() {
+			continue
+		}
+		err = fct(th.Name, tr)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func hasTarExt(name string) bool {
+	for _, ext := range tarExts {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// extractFile writes the content of r to the named file, under dir.
+func extractFile(dir, name string, r io.Reader) (string, error) {
+	oname := filepath.Join(dir, name)
+	if !strings.HasPrefix(oname, filepath.Clean(dir)+string(filepath.Separator)) {
+		return "", errors.Errorf("invalid archive file name %q", name)
+	}
+
+	err := os.MkdirAll(filepath.Dir(oname), 0755)
+	if err != nil {
+		return "", errors.Wrapf(err, "could not create base directory for output partial event file %q", oname)
+	}
+	o, err := os.Create(oname)
+	if err != nil {
+		return "", errors.Wrapf(err, "could not create temporary output partial event file %q", oname)
+	}
+	defer o.Close()
+
+	_, err = io.Copy(o, r)
+	if err != nil {
+		return "", errors.Wrapf(err, "could not extract partial event file %q", name)
+	}
+	return oname, o.Close()
+}
@@ -0,0 +1,294 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package trackml
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/sbinet/go-trackml/internal/testevent"
+)
+
+func TestCompressedDataset(t *testing.T) {
+	dir, err := ioutil.TempDir("", "trkml-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	plain := filepath.Join(dir, "plain")
+	err = os.Mkdir(plain, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	evt := testevent.New(100, 10)
+	_, err = evt.Write(plain, "event000000001")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := ReadMcEvent(plain, "event000000001")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := evt.Files("event000000001")
+
+	for _, tc := range []struct {
+		name  string
+		write func(fname string) error
+	}{
+		{
+			name:  "gz",
+			write: writeDir(files, ".gz", gzipFile),
+		},
+		{
+			name:  "zst",
+			write: writeDir(files, ".zst", zstdFile),
+		},
+		{
+			// compression is detected from the content.
+			name:  "gz-noext",
+			write: writeDir(files, "", gzipFile),
+		},
+		{
+			name:  "gz.zip",
+			write: writeZip(files, ".gz", gzipFile),
+		},
+		{
+			name:  "dataset.tar",
+			write: writeTar(files, nil),
+		},
+		{
+			name:  "dataset.tar.gz",
+			write: writeTar(files, gzipFile),
+		},
+		{
+			name:  "dataset.tar.zst",
+			write: writeTar(files, zstdFile),
+		},
+		{
+			// tar file detected from its magic bytes.
+			name:  "tgz-noext.dat",
+			write: writeTar(files, gzipFile),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fname := filepath.Join(dir, tc.name)
+			err := tc.write(fname)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ds, err := NewDataset(fname, 0, -1, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer ds.Close()
+
+			names := ds.Names()
+			if len(names) != 1 || filepath.Base(names[0]) != "event000000001" {
+				t.Fatalf("invalid names: %v", names)
+			}
+
+			// tar archives are extracted once, by NewDataset.
+			extracted := ds.Path() != fname
+			if tar := strings.Contains(tc.name, "tar") || strings.HasPrefix(tc.name, "tgz"); extracted != tar {
+				t.Fatalf("invalid dataset path %q", ds.Path())
+			}
+			if extracted {
+				err = os.Remove(fname)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			n := 0
+			for ds.Next() {
+				got := ds.Event()
+				if !reflect.DeepEqual(got, want) {
+					t.Fatalf("compressed and plain events differ")
+				}
+				n++
+			}
+			if err := ds.Err(); err != nil {
+				t.Fatal(err)
+			}
+			if n != 1 {
+				t.Fatalf("invalid number of events: got=%d, want=1", n)
+			}
+
+			err = CheckSchema(ds.Path(), "event000000001")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = ds.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := os.Stat(ds.Path()); extracted && !os.IsNotExist(err) {
+				t.Fatalf("extracted files not removed: %v", err)
+			}
+		})
+	}
+}
+
+func TestUnknownArchive(t *testing.T) {
+	f, err := ioutil.TempFile("", "trkml-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	_, err = f.WriteString("not an archive\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	_, err = NewDataset(f.Name(), 0, -1, nil)
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	_, err = ReadEvent(f.Name(), "event000000001")
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+}
+
+type compressor func(w io.Writer) (io.WriteCloser, error)
+
+func gzipFile(w io.Writer) (io.WriteCloser, error) { return gzip.NewWriter(w), nil }
+func zstdFile(w io.Writer) (io.WriteCloser, error) { return zstd.NewWriter(w) }
+
+func writeCompressed(w io.Writer, comp compressor, raw []byte) error {
+	if comp == nil {
+		_, err := w.Write(raw)
+		return err
+	}
+	cw, err := comp(w)
+	if err != nil {
+		return err
+	}
+	_, err = cw.Write(raw)
+	if err != nil {
+		return err
+	}
+	return cw.Close()
+}
+
+// writeDir writes the provided files, compressed, into a directory.
+func writeDir(files map[string][]byte, ext string, comp compressor) func(string) error {
+	return func(dir string) error {
+		err := os.Mkdir(dir, 0755)
+		if err != nil {
+			return err
+		}
+		for name, raw := range files {
+			var buf bytes.Buffer
+			err := writeCompressed(&buf, comp, raw)
+			if err != nil {
+				return err
+			}
+			err = ioutil.WriteFile(filepath.Join(dir, name+ext), buf.Bytes(), 0644)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// writeZip writes the provided files, compressed, into a zip file.
+func writeZip(files map[string][]byte, ext string, comp compressor) func(string) error {
+	return func(fname string) error {
+		return writeZipFile(fname, files, ext, comp)
+	}
+}
+
+func writeZipFile(fname string, files map[string][]byte, ext string, comp compressor) error {
+	f, err := os.Create(fname)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	for name, raw := range files {
+		w, err := zw.Create("dataset/" + name + ext)
+		if err != nil {
+			return err
+		}
+		err = writeCompressed(w, comp, raw)
+		if err != nil {
+			return err
+		}
+	}
+	err = zw.Close()
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// writeTar writes the provided files into a tar file, compressed as a whole.
+func writeTar(files map[string][]byte, comp compressor) func(string) error {
+	return func(fname string) error {
+		return writeTarFile(fname, files, comp)
+	}
+}
+
+func writeTarFile(fname string, files map[string][]byte, comp compressor) error {
+	f, err := os.Create(fname)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var w io.WriteCloser = f
+	if comp != nil {
+		w, err = comp(f)
+		if err != nil {
+			return err
+		}
+	}
+
+	tw := tar.NewWriter(w)
+	for name, raw := range files {
+		err = tw.WriteHeader(&tar.Header{
+			Name: "dataset/" + name,
+			Mode: 0644,
+			Size: int64(len(raw)),
+		})
+		if err != nil {
+			return err
+		}
+		_, err = tw.Write(raw)
+		if err != nil {
+			return err
+		}
+	}
+	err = tw.Close()
+	if err != nil {
+		return err
+	}
+	if comp != nil {
+		err = w.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return f.Close()
+}
@@ -0,0 +1,213 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package trackml
+
+import (
+	"io/fs"
+	"path"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// Blacklist holds the hits and particles of an event that should be
+// excluded, as listed by the eventNNN-blacklist_hits.csv and
+// eventNNN-blacklist_particles.csv files of later TrackML releases.
+//
+// Hits of blacklisted particles are blacklisted too.
+type Blacklist struct {
+	Hits      []int // sorted IDs of blacklisted hits
+	Particles []int // sorted IDs of blacklisted particles
+}
+
+// Empty returns whether the blacklist holds no hit and no particle.
+func (bl Blacklist) Empty() bool {
+	return len(bl.Hits) == 0 && len(bl.Particles) == 0
+}
+
+// HasHit returns whether the hit with the provided ID is blacklisted.
+func (bl Blacklist) HasHit(id int) bool {
+	return hasID(bl.Hits, id)
+}
+
+// HasParticle returns whether the particle with the provided ID is
+// blacklisted.
+func (bl Blacklist) HasParticle(id int) bool {
+	return hasID(bl.Particles, id)
+}
+
+func hasID(ids []int, id int) bool {
+	i := sort.SearchInts(ids, id)
+	return i < len(ids) && ids[i] == id
+}
+
+// ReadBlacklist reads the blacklist of an event from the given path+prefix.
+// Missing blacklist files are considered empty.
+func ReadBlacklist(path, evtid string) (Blacklist, error) {
+	return ReadBlacklistFS(osFS{}, path, evtid)
+}
+
+// ReadBlacklistFS is like ReadBlacklist but reads the blacklist from the named
+// directory or archive of fsys.
+func ReadBlacklistFS(fsys fs.FS, name, evtid string) (Blacklist, error) {
+	ds, err := openDataset(fsys, name, evtid)
+	if err != nil {
+		return Blacklist{}, errors.Wrapf(err, "could not open resource %q", name)
+	}
+	defer ds.Close()
+
+	return readBlacklist(ds.fsys, ds.dir, evtid)
+}
+
+func readBlacklist(fsys fs.FS, dir, evtid string) (Blacklist, error) {
+	var (
+		bl    Blacklist
+		fname = path.Join(dir, evtid)
+		hits  []struct {
+			ID int `csv:"hit_id"`
+		}
+		ps []struct {
+			ID int `csv:"particle_id"`
+		}
+	)
+
+	err := readOptionalCSV(fsys, fname+"-blacklist_hits.csv", &hits)
+	if err != nil {
+		return bl, errors.Wrapf(err, "could not read blacklisted hits")
+	}
+	for _, hit := range hits {
+		bl.Hits = append(bl.Hits, hit.ID)
+	}
+	sort.Ints(bl.Hits)
+
+	err = readOptionalCSV(fsys, fname+"-blacklist_particles.csv", &ps)
+	if err != nil {
+		return bl, errors.Wrapf(err, "could not read blacklisted particles")
+	}
+	for _, p := range ps {
+		bl.Particles = append(bl.Particles, p.ID)
+	}
+	sort.Ints(bl.Particles)
+
+	return bl, nil
+}
+
+// readOptionalCSV is like ReadCSV for a file of fsys, but leaves ptr
+// untouched if the file does not exist.
+func readOptionalCSV(fsys fs.FS, fname string, ptr interface{}) error {
+	f, err := openFile(fsys, fname)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+
+	return decodeCSV(f, fname, ptr)
+}
+
+// WithBlacklist returns an EventReader that reads events with reader and
+// attaches to them their blacklist, read from the dataset at path.
+//
+// It allows to use the blacklist files of a dataset shipped separately,
+// such as blacklist_training.zip:
+//
+//	ds, err := NewDataset("train_1.zip", 0, -1, WithBlacklist("blacklist_training.zip", nil))
+//
+// If reader is nil, ReadMcEvent is used.
+func WithBlacklist(path string, reader EventReader) EventReader {
+	if reader == nil {
+		reader = ReadMcEvent
+	}
+	return func(name, evtid string) (Event, error) {
+		evt, err := reader(name, evtid)
+		if err != nil {
+			return evt, err
+		}
+		evt.Blacklist, err = ReadBlacklist(path, evtid)
+		if err != nil {
+			return evt, errors.Wrapf(err, "could not read blacklist")
+		}
+		return evt, nil
+	}
+}
+
+// WithoutBlacklisted returns an EventReader that reads events with reader and
+// removes their blacklisted hits and particles, with RemoveBlacklisted.
+//
+// If reader is nil, ReadMcEvent is used.
+func WithoutBlacklisted(reader EventReader) EventReader {
+	if reader == nil {
+		reader = ReadMcEvent
+	}
+	return func(name, evtid string) (Event, error) {
+		evt, err := reader(name, evtid)
+		if err != nil {
+			return evt, err
+		}
+		evt.RemoveBlacklisted()
+		return evt, nil
+	}
+}
+
+// blacklisted returns the IDs of the blacklisted hits of the event, including
+// the hits of blacklisted particles.
+func (evt *Event) blacklisted() map[int]bool {
+	bl := evt.Blacklist
+	ids := make(map[int]bool, len(bl.Hits))
+	for _, id := range bl.Hits {
+		ids[id] = true
+	}
+	if len(bl.Particles) > 0 {
+		for _, mc := range evt.Mcs {
+			if bl.HasParticle(mc.PID) {
+				ids[mc.HitID] = true
+			}
+		}
+	}
+	return ids
+}
+
+// RemoveBlacklisted removes the blacklisted hits of the event, with their
+// cells and truth, and the blacklisted particles.
+func (evt *Event) RemoveBlacklisted() {
+	if evt.Blacklist.Empty() {
+		return
+	}
+	ids := evt.blacklisted()
+
+	hits := evt.Hits[:0]
+	for _, hit := range evt.Hits {
+		if !ids[hit.HitID] {
+			hits = append(hits, hit)
+		}
+	}
+	evt.Hits = hits
+
+	cells := evt.Cells[:0]
+	for _, cell := range evt.Cells {
+		if !ids[cell.HitID] {
+			cells = append(cells, cell)
+		}
+	}
+	evt.Cells = cells
+
+	mcs := evt.Mcs[:0]
+	for _, mc := range evt.Mcs {
+		if !ids[mc.HitID] {
+			mcs = append(mcs, mc)
+		}
+	}
+	evt.Mcs = mcs
+
+	ps := evt.Ps[:0]
+	for _, p := range evt.Ps {
+		if !evt.Blacklist.HasParticle(p.ID) {
+			ps = append(ps, p)
+		}
+	}
+	evt.Ps = ps
+}
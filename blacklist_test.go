@@ -0,0 +1,173 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package trackml
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/sbinet/go-trackml/internal/testevent"
+)
+
+func TestReadBlacklist(t *testing.T) {
+	dir, err := ioutil.TempDir("", "trkml-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	prefix, err := testevent.New(100, 10).Write(dir, "event000000001")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	evt, err := ReadMcEvent(dir, "event000000001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !evt.Blacklist.Empty() {
+		t.Fatalf("invalid blacklist: got=%v, want an empty one", evt.Blacklist)
+	}
+
+	err = ioutil.WriteFile(prefix+"-blacklist_hits.csv", []byte("hit_id\n42\n3\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(prefix+"-blacklist_particles.csv", []byte("particle_id\n4503599627370497\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Blacklist{
+		Hits:      []int{3, 42},
+		Particles: []int{4503599627370497},
+	}
+	evt, err = ReadMcEvent(dir, "event000000001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(evt.Blacklist, want) {
+		t.Fatalf("invalid blacklist:\ngot = %v\nwant= %v", evt.Blacklist, want)
+	}
+
+	// blacklist shipped separately from the events.
+	bldir := filepath.Join(dir, "blacklist")
+	err = os.Mkdir(bldir, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"hits", "particles"} {
+		err = os.Rename(
+			prefix+"-blacklist_"+name+".csv",
+			filepath.Join(bldir, "event000000001-blacklist_"+name+".csv"),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ds, err := NewDataset(dir, 0, -1, WithBlacklist(bldir, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ds.Close()
+	if !ds.Next() {
+		t.Fatalf("could not read event: %v", ds.Err())
+	}
+	evt = ds.Event()
+	if !reflect.DeepEqual(evt.Blacklist, want) {
+		t.Fatalf("invalid blacklist:\ngot = %v\nwant= %v", evt.Blacklist, want)
+	}
+	if got, want := len(evt.Hits), 100; got != want {
+		t.Fatalf("invalid number of hits: got=%d, want=%d", got, want)
+	}
+
+	evt, err = WithoutBlacklisted(WithBlacklist(bldir, nil))(dir, "event000000001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// hits 3 and 42, and the 10 hits (11 to 20) of particle 4503599627370497.
+	if got, want := len(evt.Hits), 88; got != want {
+		t.Fatalf("invalid number of hits: got=%d, want=%d", got, want)
+	}
+	if got, want := len(evt.Cells), 2*88; got != want {
+		t.Fatalf("invalid number of cells: got=%d, want=%d", got, want)
+	}
+	if got, want := len(evt.Ps), 9; got != want {
+		t.Fatalf("invalid number of particles: got=%d, want=%d", got, want)
+	}
+	for i, mc := range evt.Mcs {
+		if mc.HitID != evt.Hits[i].HitID {
+			t.Fatalf("hits and truth differ at row %d", i)
+		}
+		if evt.Blacklist.HasHit(mc.HitID) || evt.Blacklist.HasParticle(mc.PID) {
+			t.Fatalf("blacklisted hit %d not removed", mc.HitID)
+		}
+	}
+}
+
+func TestScoreTruthOrder(t *testing.T) {
+	labels := []int{0, 0, 0, 0, 1, 1, 1, 1}
+	for len(labels) < 13 {
+		labels = append(labels, Unassigned)
+	}
+
+	for _, bl := range []Blacklist{{}, {Hits: []int{13}}} {
+		evt := newTestEvent()
+		evt.Blacklist = bl
+		// truth rows in reverse order, without the last noise hits.
+		var mcs []Truth
+		for i := len(evt.Mcs) - 1; i >= 0; i-- {
+			mcs = append(mcs, evt.Mcs[i])
+		}
+		evt.Mcs = mcs[3:]
+		orig := append([]Truth(nil), evt.Mcs...)
+
+		got := Score(evt, labels)
+		if math.Abs(got-1) > 1e-12 {
+			t.Fatalf("invalid score: got=%v, want=1", got)
+		}
+		if !reflect.DeepEqual(evt.Mcs, orig) {
+			t.Fatalf("truth modified")
+		}
+	}
+}
+
+func TestScoreBlacklist(t *testing.T) {
+	// both particles merged into a single track.
+	labels := []int{0, 0, 0, 0, 0, 0, 0, 0}
+	for len(labels) < 13 {
+		labels = append(labels, Unassigned)
+	}
+
+	for _, tc := range []struct {
+		name string
+		bl   Blacklist
+		want float64
+	}{
+		{"none", Blacklist{}, 0},
+		{"particle", Blacklist{Particles: []int{2}}, 1},
+		{"hits", Blacklist{Hits: []int{5, 6, 7}}, 0.5 / 0.625},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			evt := newTestEvent()
+			evt.Blacklist = tc.bl
+
+			got := Score(evt, labels)
+			if math.Abs(got-tc.want) > 1e-12 {
+				t.Fatalf("invalid score: got=%v, want=%v", got, tc.want)
+			}
+
+			ms := MatchTracks(evt, labels)
+			if len(ms) != 1 {
+				t.Fatalf("invalid number of tracks: got=%d, want=1", len(ms))
+			}
+		})
+	}
+}
@@ -9,11 +9,63 @@ import (
 )
 
 // Classifier clusters hits.
+//
+// Predict returns one label per hit.
+// Hits that could not be attached to any track are labeled with
+// trackml.Unassigned.
 type Classifier interface {
 	Predict(hits []trackml.Hit) ([]int, error)
 }
 
+// EventClassifier clusters the hits of an event, using the other data of
+// the event, such as its cells.
+//
+// PredictEvent returns one label per hit of the event, as Classifier.Predict.
+type EventClassifier interface {
+	PredictEvent(evt trackml.Event) ([]int, error)
+}
+
+// PredictEvent clusters the hits of the provided event with c.
+// If c is an EventClassifier, its PredictEvent method is used.
+func PredictEvent(c Classifier, evt trackml.Event) ([]int, error) {
+	if ec, ok := c.(EventClassifier); ok {
+		return ec.PredictEvent(evt)
+	}
+	return c.Predict(evt.Hits)
+}
+
+// Finder finds candidate tracks from a list of hits.
+//
+// Find returns the candidate tracks as lists of indices into hits.
+// Candidate tracks may share hits.
+type Finder interface {
+	Find(hits []trackml.Hit) ([][]int, error)
+}
+
+// Resolver assigns a label to each hit from a list of candidate tracks,
+// resolving the hits shared between candidates.
+type Resolver interface {
+	Resolve(hits []trackml.Hit, tracks [][]int) ([]int, error)
+}
+
+// New returns a Hough transform based classifier.
+//
+// Overlapping candidate tracks are resolved in theta order, with FirstCome.
 func New(nWorkers, nbinsR0Inv, nbinsGamma, nbinsTheta, minHits int) Classifier {
+	return newHough(nWorkers, nbinsR0Inv, nbinsGamma, nbinsTheta, minHits)
+}
+
+// NewFinder returns a Hough transform based track finder.
+func NewFinder(nWorkers, nbinsR0Inv, nbinsGamma, nbinsTheta, minHits int) Finder {
+	return newHough(nWorkers, nbinsR0Inv, nbinsGamma, nbinsTheta, minHits)
+}
+
+type houghClassifier interface {
+	Classifier
+	Finder
+}
+
+func newHough(nWorkers, nbinsR0Inv, nbinsGamma, nbinsTheta, minHits int) houghClassifier {
 	switch {
 	case nWorkers > 1:
 		return &pcluster{
@@ -32,3 +84,66 @@ func New(nWorkers, nbinsR0Inv, nbinsGamma, nbinsTheta, minHits int) Classifier {
 		}
 	}
 }
+
+// NewPipeline returns a classifier finding candidate tracks with f, and
+// resolving them with r.
+func NewPipeline(f Finder, r Resolver) Classifier {
+	return &pipeline{f: f, r: r}
+}
+
+type pipeline struct {
+	f Finder
+	r Resolver
+}
+
+// Predict clusters hits.
+func (p *pipeline) Predict(hits []trackml.Hit) ([]int, error) {
+	tracks, err := p.f.Find(hits)
+	if err != nil {
+		return nil, err
+	}
+	return p.r.Resolve(hits, tracks)
+}
+
+// FirstCome resolves candidate tracks in the order they are given.
+// Hits already claimed by a previous candidate are removed from the following
+// ones, and candidates left with less than MinHits hits are dropped.
+type FirstCome struct {
+	MinHits int
+}
+
+// Resolve assigns labels to hits.
+func (fc FirstCome) Resolve(hits []trackml.Hit, tracks [][]int) ([]int, error) {
+	return labelTracks(tracks, len(hits), fc.MinHits), nil
+}
+
+// labelTracks assigns a track ID to each of the nhits hits, from the
+// list of candidate tracks.
+// Candidate tracks are considered in order: hits already claimed by a previous
+// track are removed from the candidate, and the candidate is then kept only if
+// it still has at least minHits hits.
+// Hits not claimed by any track are labeled with trackml.Unassigned.
+func labelTracks(tracks [][]int, nhits, minHits int) []int {
+	trackID := 0
+	labels := make([]int, nhits)
+	for i := range labels {
+		labels[i] = trackml.Unassigned
+	}
+	used := make(map[int]struct{}, nhits)
+	for _, hits := range tracks {
+		slice := make([]int, 0, len(hits))
+		for _, hit := range hits {
+			if _, dup := used[hit]; !dup {
+				slice = append(slice, hit)
+			}
+		}
+		if len(slice) >= minHits {
+			for _, v := range slice {
+				labels[v] = trackID
+				used[v] = struct{}{}
+			}
+			trackID++
+		}
+	}
+	return labels
+}
@@ -0,0 +1,132 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package clustering
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	trackml "github.com/sbinet/go-trackml"
+)
+
+func TestLabelTracks(t *testing.T) {
+	const u = trackml.Unassigned
+	tracks := [][]int{
+		{0, 1, 2},
+		{2, 3, 4}, // shares hit 2 with the first track, too short afterwards.
+		{5, 6, 7},
+	}
+	got := labelTracks(tracks, 10, 3)
+	want := []int{0, 0, 0, u, u, 1, 1, 1, u, u}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("label error\ngot = %v\nwant= %v", got, want)
+	}
+}
+
+// helix returns n hits along a helix starting from the origin, with
+// transverse radius r, initial azimuthal angle phi and cotangent of the polar
+// angle cot, crossing layers 1 to n.
+func helix(n int, r, phi, cot float64) []trackml.Hit {
+	var (
+		hits = make([]trackml.Hit, n)
+		cx   = r * math.Cos(phi+math.Pi/2)
+		cy   = r * math.Sin(phi+math.Pi/2)
+	)
+	for i := range hits {
+		a := 0.05 * float64(i+1)
+		hits[i] = trackml.Hit{
+			X:        cx + r*math.Cos(phi-math.Pi/2+a),
+			Y:        cy + r*math.Sin(phi-math.Pi/2+a),
+			Z:        cot * r * a,
+			VolumeID: 8,
+			LayerID:  2 * (i + 1),
+		}
+	}
+	return hits
+}
+
+func TestMerger(t *testing.T) {
+	const (
+		u       = trackml.Unassigned
+		minHits = 5
+	)
+	var hits []trackml.Hit
+	hits = append(hits, helix(10, 1000, 0.5, 0.2)...)
+	hits = append(hits, helix(10, 2000, 2.5, -1)...)
+	hits = append(hits, trackml.Hit{X: 10, Y: 10, Z: 10, VolumeID: 7, LayerID: 2})
+	for i := range hits {
+		hits[i].HitID = i + 1
+	}
+
+	var (
+		trkA = []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+		trkB = []int{10, 11, 12, 13, 14, 15, 16, 17, 18, 19}
+		junk = []int{0, 1, 2, 3, 4, 10, 11, 12, 13, 14}
+	)
+	tracks := [][]int{
+		junk,
+		trkA[:6],
+		trkA,
+		trkB[2:],
+		trkB,
+		trkA[:6],
+	}
+
+	for _, tc := range []struct {
+		name string
+		r    Resolver
+		want []int
+	}{
+		{
+			name: "first-come",
+			r:    FirstCome{MinHits: minHits},
+			want: []int{
+				0, 0, 0, 0, 0, 1, 1, 1, 1, 1,
+				0, 0, 0, 0, 0, 2, 2, 2, 2, 2,
+				u,
+			},
+		},
+		{
+			name: "merger",
+			r:    NewMerger(minHits),
+			want: []int{
+				0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+				1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
+				u,
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := tc.r.Resolve(hits, tracks)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("label error\ngot = %v\nwant= %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMergerChi2(t *testing.T) {
+	hits := helix(10, 1000, 0.5, 0.2)
+	hits = append(hits, helix(10, 300, -1, 2)...)
+	m := NewMerger(5)
+	trkA := []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+	chi2, ndf := m.chi2(hits, trkA)
+	if ndf != 15 {
+		t.Fatalf("invalid ndf: got=%d, want=%d", ndf, 15)
+	}
+	if chi2 > 1e-6 {
+		t.Fatalf("invalid chi2 for a perfect helix: %v", chi2)
+	}
+
+	junk := []int{0, 1, 2, 3, 4, 10, 11, 12, 13, 14}
+	chi2, ndf = m.chi2(hits, junk)
+	if chi2/float64(ndf) < 100 {
+		t.Fatalf("invalid chi2/ndf for two helices: %v", chi2/float64(ndf))
+	}
+}
@@ -0,0 +1,283 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package clustering
+
+import (
+	"math"
+	"sort"
+
+	"github.com/pkg/errors"
+	trackml "github.com/sbinet/go-trackml"
+	"github.com/sbinet/go-trackml/hitgraph"
+	"github.com/sbinet/go-trackml/nn"
+)
+
+// Edge is a scored segment between two hits.
+type Edge struct {
+	HitID1 int     `csv:"hit_id_1"` // hit ID of the first hit
+	HitID2 int     `csv:"hit_id_2"` // hit ID of the second hit
+	Score  float64 `csv:"score"`    // probability for both hits to belong to the same track
+}
+
+// EdgeScorer returns the scored edges between the hits of the provided
+// event.
+type EdgeScorer func(evt trackml.Event) ([]Edge, error)
+
+// Edges returns an EdgeScorer always returning the provided edges.
+// Edges with hits not in the list of hits to cluster are ignored.
+func Edges(edges []Edge) EdgeScorer {
+	return func(trackml.Event) ([]Edge, error) { return edges, nil }
+}
+
+// ModelScorer returns an EdgeScorer scoring the candidate edges of the hit
+// graphs built by b, with the first output of the provided model.
+//
+// The inputs of the model are given by hitgraph.Graph.EdgeInputs.
+// The node features are computed from the hits and cells of each scored
+// event.
+func ModelScorer(b *hitgraph.Builder, m *nn.Model) EdgeScorer {
+	return func(evt trackml.Event) ([]Edge, error) {
+		hits := evt.Hits
+		g := b.Build(trackml.Event{Hits: hits, Cells: evt.Cells})
+		x := g.EdgeInputs()
+		if x == nil {
+			return nil, nil
+		}
+		if _, c := x.Dims(); c != m.Inputs {
+			return nil, errors.Errorf("clustering: model expects %d inputs, got %d", m.Inputs, c)
+		}
+		y := m.Predict(x)
+		edges := make([]Edge, len(g.Senders))
+		for k, i := range g.Senders {
+			edges[k] = Edge{
+				HitID1: hits[i].HitID,
+				HitID2: hits[g.Receivers[k]].HitID,
+				Score:  y.At(k, 0),
+			}
+		}
+		return edges, nil
+	}
+}
+
+// ReadEdges reads scored edges from a CSV file with a header line and the
+// columns hit_id_1, hit_id_2 and score.
+func ReadEdges(fname string) ([]Edge, error) {
+	var edges []Edge
+	err := trackml.ReadCSV(fname, &edges)
+	if err != nil {
+		return nil, errors.Wrapf(err, "clustering: could not read edges")
+	}
+	return edges, nil
+}
+
+// GraphFinder builds tracks from scored edges between hits.
+//
+// Edges with a score below Threshold are dropped.
+// By default, tracks are the connected components of the remaining graph.
+// When Walk is set, tracks are instead built by walking the graph outwards,
+// from the innermost unassigned hits: at each branching, the walk follows
+// the edge with the highest score to an unassigned hit.
+// Tracks with less than MinHits hits are dropped.
+type GraphFinder struct {
+	Scorer    EdgeScorer
+	Threshold float64
+	Walk      bool
+	MinHits   int
+}
+
+// NewGraphFinder returns a GraphFinder building tracks from connected
+// components of the edges with a score of at least 0.5.
+func NewGraphFinder(scorer EdgeScorer, minHits int) *GraphFinder {
+	return &GraphFinder{
+		Scorer:    scorer,
+		Threshold: 0.5,
+		MinHits:   minHits,
+	}
+}
+
+// Predict clusters hits.
+// The hits are scored as an event without cells: scorers using the cells
+// of the hits, such as ModelScorer, should be run through PredictEvent.
+func (gf *GraphFinder) Predict(hits []trackml.Hit) ([]int, error) {
+	return gf.PredictEvent(trackml.Event{Hits: hits})
+}
+
+// PredictEvent clusters the hits of the provided event.
+func (gf *GraphFinder) PredictEvent(evt trackml.Event) ([]int, error) {
+	tracks, err := gf.FindEvent(evt)
+	if err != nil {
+		return nil, err
+	}
+	return labelTracks(tracks, len(evt.Hits), gf.MinHits), nil
+}
+
+// Find returns the tracks, which do not share hits, by decreasing number
+// of hits.
+// The hits are scored as an event without cells, see FindEvent.
+func (gf *GraphFinder) Find(hits []trackml.Hit) ([][]int, error) {
+	return gf.FindEvent(trackml.Event{Hits: hits})
+}
+
+// FindEvent returns the tracks of the hits of the provided event, which do
+// not share hits, by decreasing number of hits.
+func (gf *GraphFinder) FindEvent(evt trackml.Event) ([][]int, error) {
+	hits := evt.Hits
+	edges, err := gf.Scorer(evt)
+	if err != nil {
+		return nil, errors.Wrapf(err, "clustering: could not score edges")
+	}
+
+	idx := make(map[int]int, len(hits))
+	for i, hit := range hits {
+		idx[hit.HitID] = i
+	}
+
+	var links []link
+	for _, e := range edges {
+		if e.Score < gf.Threshold {
+			continue
+		}
+		i, ok := idx[e.HitID1]
+		if !ok {
+			continue
+		}
+		j, ok := idx[e.HitID2]
+		if !ok || i == j {
+			continue
+		}
+		links = append(links, link{i, j, e.Score})
+	}
+
+	var tracks [][]int
+	switch {
+	case gf.Walk:
+		tracks = walk(hits, links, gf.MinHits)
+	default:
+		tracks = components(len(hits), links)
+	}
+
+	o := tracks[:0]
+	for _, trk := range tracks {
+		if len(trk) >= gf.MinHits {
+			o = append(o, trk)
+		}
+	}
+	tracks = o
+	sort.SliceStable(tracks, func(i, j int) bool { return len(tracks[i]) > len(tracks[j]) })
+	return tracks, nil
+}
+
+// link is an edge between the hits of indices i and j.
+type link struct {
+	i, j  int
+	score float64
+}
+
+// components returns the connected components, with at least 2 nodes, of
+// the graph of n nodes with the provided links.
+func components(n int, links []link) [][]int {
+	parent := make([]int, n)
+	for i := range parent {
+		parent[i] = i
+	}
+	find := func(i int) int {
+		for parent[i] != i {
+			parent[i] = parent[parent[i]]
+			i = parent[i]
+		}
+		return i
+	}
+	for _, l := range links {
+		ri, rj := find(l.i), find(l.j)
+		if ri != rj {
+			parent[rj] = ri
+		}
+	}
+
+	var (
+		ids    = make(map[int]int)
+		tracks [][]int
+	)
+	for i := 0; i < n; i++ {
+		r := find(i)
+		id, ok := ids[r]
+		if !ok {
+			id = len(tracks)
+			ids[r] = id
+			tracks = append(tracks, nil)
+		}
+		tracks[id] = append(tracks[id], i)
+	}
+
+	o := tracks[:0]
+	for _, trk := range tracks {
+		if len(trk) > 1 {
+			o = append(o, trk)
+		}
+	}
+	return o
+}
+
+// walk returns the tracks built by walking the graph of the provided links
+// outwards from the innermost hits.
+// Walks with less than minHits hits are discarded and their hits released.
+func walk(hits []trackml.Hit, links []link, minHits int) [][]int {
+	var (
+		dist = make([]float64, len(hits))
+		next = make([][]link, len(hits))
+	)
+	for i, hit := range hits {
+		dist[i] = math.Sqrt(hit.X*hit.X + hit.Y*hit.Y + hit.Z*hit.Z)
+	}
+	for _, l := range links {
+		if dist[l.j] < dist[l.i] {
+			l.i, l.j = l.j, l.i
+		}
+		next[l.i] = append(next[l.i], l)
+	}
+
+	order := make([]int, len(hits))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return dist[order[i]] < dist[order[j]] })
+
+	var (
+		used   = make([]bool, len(hits))
+		tracks [][]int
+	)
+	for _, i := range order {
+		if used[i] || len(next[i]) == 0 {
+			continue
+		}
+		trk := []int{i}
+		used[i] = true
+		for cur := i; ; {
+			best := -1
+			score := math.Inf(-1)
+			for _, l := range next[cur] {
+				if used[l.j] || l.score <= score {
+					continue
+				}
+				best = l.j
+				score = l.score
+			}
+			if best < 0 {
+				break
+			}
+			trk = append(trk, best)
+			used[best] = true
+			cur = best
+		}
+		if len(trk) < 2 || len(trk) < minHits {
+			for _, j := range trk {
+				used[j] = false
+			}
+			continue
+		}
+		tracks = append(tracks, trk)
+	}
+	return tracks
+}
@@ -0,0 +1,216 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package clustering
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	trackml "github.com/sbinet/go-trackml"
+	"github.com/sbinet/go-trackml/hitgraph"
+	"github.com/sbinet/go-trackml/layers"
+	"github.com/sbinet/go-trackml/nn"
+	"gonum.org/v1/gonum/mat"
+)
+
+var (
+	_ Classifier = (*GraphFinder)(nil)
+	_ Finder     = (*GraphFinder)(nil)
+)
+
+func TestGraphFinder(t *testing.T) {
+	const u = trackml.Unassigned
+
+	var hits []trackml.Hit
+	hits = append(hits, helix(10, 1000, 0.5, 0.2)...)
+	hits = append(hits, helix(10, 2000, 2.5, -1)...)
+	hits = append(hits, trackml.Hit{X: 10, Y: 10, Z: 10, VolumeID: 7, LayerID: 2})
+	for i := range hits {
+		hits[i].HitID = i + 1
+	}
+
+	var edges []Edge
+	for i := 1; i < 10; i++ {
+		edges = append(edges,
+			Edge{HitID1: i, HitID2: i + 1, Score: 0.9},
+			Edge{HitID1: i + 11, HitID2: i + 10, Score: 0.8}, // inwards
+		)
+	}
+	edges = append(edges,
+		Edge{HitID1: 5, HitID2: 16, Score: 0.7},  // branching between both tracks
+		Edge{HitID1: 5, HitID2: 21, Score: 0.3},  // below threshold
+		Edge{HitID1: 5, HitID2: 100, Score: 0.9}, // unknown hit
+	)
+
+	for _, tc := range []struct {
+		name string
+		walk bool
+		want []int
+	}{
+		{
+			name: "components",
+			want: []int{
+				0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+				0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+				u,
+			},
+		},
+		{
+			name: "walk",
+			walk: true,
+			want: []int{
+				0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+				1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
+				u,
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			gf := NewGraphFinder(Edges(edges), 5)
+			gf.Walk = tc.walk
+			got, err := gf.Predict(hits)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("label error\ngot = %v\nwant= %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestReadEdges(t *testing.T) {
+	dir, err := ioutil.TempDir("", "trackml-clustering-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fname := filepath.Join(dir, "edges.csv")
+	err = ioutil.WriteFile(fname, []byte("hit_id_1,hit_id_2,score\n1,2,0.5\n3,4,0.125\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ReadEdges(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Edge{{1, 2, 0.5}, {3, 4, 0.125}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("invalid edges\ngot = %v\nwant= %v", got, want)
+	}
+}
+
+func TestModelScorer(t *testing.T) {
+	const u = trackml.Unassigned
+
+	var evt trackml.Event
+	evt.Hits = append(evt.Hits, helix(10, 1000, 0.5, 0.2)...)
+	evt.Hits = append(evt.Hits, helix(10, 2000, 2.5, -1)...)
+	evt.Ps = []trackml.Particle{{ID: 1}, {ID: 2}}
+	for i := range evt.Hits {
+		evt.Hits[i].HitID = i + 1
+		evt.Mcs = append(evt.Mcs, trackml.Truth{HitID: i + 1, PID: 1 + i/10})
+	}
+
+	lg := layers.New()
+	lg.Add(evt)
+	b := hitgraph.New(lg)
+
+	// model returning sigmoid(bias), whatever the inputs.
+	model := func(bias float64) *nn.Model {
+		ninputs := 2*len(hitgraph.NodeFeatures) + len(hitgraph.EdgeFeatures)
+		return &nn.Model{
+			Inputs: ninputs,
+			Layers: []nn.Layer{
+				&nn.Dense{W: mat.NewDense(ninputs, 1, nil), B: []float64{bias}},
+				nn.Sigmoid{},
+			},
+		}
+	}
+
+	for _, tc := range []struct {
+		name string
+		bias float64
+		want []int
+	}{
+		{
+			name: "accept",
+			bias: +5,
+			want: []int{
+				0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+				1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
+			},
+		},
+		{
+			name: "reject",
+			bias: -5,
+			want: []int{
+				u, u, u, u, u, u, u, u, u, u,
+				u, u, u, u, u, u, u, u, u, u,
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			gf := NewGraphFinder(ModelScorer(b, model(tc.bias)), 5)
+			gf.Walk = true
+			got, err := gf.Predict(evt.Hits)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("label error\ngot = %v\nwant= %v", got, tc.want)
+			}
+		})
+	}
+
+	bad := &nn.Model{Inputs: 3, Layers: []nn.Layer{nn.Sigmoid{}}}
+	_, err := NewGraphFinder(ModelScorer(b, bad), 5).Predict(evt.Hits)
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+
+	// model accepting edges whose sender hit has cells.
+	ninputs := 2*len(hitgraph.NodeFeatures) + len(hitgraph.EdgeFeatures)
+	w := mat.NewDense(ninputs, 1, nil)
+	w.Set(3, 0, 10) // ncells of the sender
+	gf := NewGraphFinder(ModelScorer(b, &nn.Model{
+		Inputs: ninputs,
+		Layers: []nn.Layer{&nn.Dense{W: w, B: []float64{-5}}, nn.Sigmoid{}},
+	}), 5)
+	gf.Walk = true
+
+	// same hits, with cells for the first particle only.
+	other := trackml.Event{Hits: evt.Hits}
+	for _, hit := range evt.Hits[:10] {
+		other.Cells = append(other.Cells, trackml.Cell{HitID: hit.HitID, Value: 1})
+	}
+	for _, hit := range evt.Hits {
+		evt.Cells = append(evt.Cells, trackml.Cell{HitID: hit.HitID, Value: 1})
+	}
+
+	for _, tc := range []struct {
+		name string
+		evt  trackml.Event
+		want []int
+	}{
+		{"all-cells", evt, []int{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1}},
+		{"some-cells", other, []int{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, u, u, u, u, u, u, u, u, u, u}},
+		{"no-cells", trackml.Event{Hits: evt.Hits}, []int{u, u, u, u, u, u, u, u, u, u, u, u, u, u, u, u, u, u, u, u}},
+		{"all-cells-again", evt, []int{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1}},
+	} {
+		got, err := gf.PredictEvent(tc.evt)
+		if err != nil {
+			t.Fatalf("%s: %+v", tc.name, err)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Fatalf("%s: label error\ngot = %v\nwant= %v", tc.name, got, tc.want)
+		}
+	}
+}
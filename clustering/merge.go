@@ -0,0 +1,212 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package clustering
+
+import (
+	"math"
+	"sort"
+
+	trackml "github.com/sbinet/go-trackml"
+	"github.com/sbinet/go-trackml/fit"
+)
+
+// Merger resolves candidate tracks by quality rather than by order.
+//
+// Identical candidates are removed and the remaining ones are ranked by quality.
+// Each candidate is then merged into the best already accepted track with which
+// it shares at least a fraction MinShared of its hits, provided the merged track
+// is still compatible with a helix.
+// Otherwise, the candidate is kept as a new track.
+// Finally, hits shared between tracks are given to the track with the highest
+// quality.
+//
+// The quality of a track is defined as:
+//
+//	q = WHits*nhits + WLayers*nlayers - WChi2*chi2/ndf
+//
+// where nlayers is the number of distinct (volume, layer) pairs crossed by the
+// track and chi2 is the one of a helix fit of its hits.
+type Merger struct {
+	MinHits   int     // minimum number of hits of a track
+	MinShared float64 // minimum fraction of shared hits to merge a candidate into a track
+	MaxChi2   float64 // maximum helix chi2/ndf of a merged track
+
+	Fitter *fit.Fitter // helix fitter
+
+	WHits   float64 // weight of the number of hits in the track quality
+	WLayers float64 // weight of the number of layers in the track quality
+	WChi2   float64 // weight of the helix chi2/ndf in the track quality
+}
+
+// NewMerger returns a Merger with default parameters.
+func NewMerger(minHits int) *Merger {
+	return &Merger{
+		MinHits:   minHits,
+		MinShared: 0.5,
+		MaxChi2:   10,
+		Fitter: &fit.Fitter{
+			Circle:  fit.Taubin,
+			BField:  2,
+			SigmaXY: 0.5,
+			SigmaZ:  2,
+		},
+		WHits:   1,
+		WLayers: 1,
+		WChi2:   0.1,
+	}
+}
+
+type candidate struct {
+	hits    []int
+	quality float64
+}
+
+// Resolve assigns labels to hits.
+func (m *Merger) Resolve(hits []trackml.Hit, tracks [][]int) ([]int, error) {
+	cands := m.candidates(hits, tracks)
+	sort.SliceStable(cands, func(i, j int) bool {
+		return cands[i].quality > cands[j].quality
+	})
+
+	var (
+		trks    []candidate
+		members = make(map[int][]int) // hit -> indices into trks
+	)
+	for _, c := range cands {
+		shared := make(map[int]int)
+		for _, hit := range c.hits {
+			for _, itrk := range members[hit] {
+				shared[itrk]++
+			}
+		}
+		best, nshared := -1, 0
+		for itrk, n := range shared {
+			if n > nshared || (n == nshared && itrk < best) {
+				best, nshared = itrk, n
+			}
+		}
+
+		if best >= 0 && float64(nshared) >= m.MinShared*float64(len(c.hits)) {
+			trk := union(trks[best].hits, c.hits)
+			chi2, ndf := m.chi2(hits, trk)
+			if ndf <= 0 || chi2/float64(ndf) <= m.MaxChi2 {
+				for _, hit := range c.hits {
+					if !contains(trks[best].hits, hit) {
+						members[hit] = append(members[hit], best)
+					}
+				}
+				trks[best] = candidate{hits: trk, quality: m.quality(hits, trk)}
+				continue
+			}
+		}
+
+		for _, hit := range c.hits {
+			members[hit] = append(members[hit], len(trks))
+		}
+		trks = append(trks, c)
+	}
+
+	sort.SliceStable(trks, func(i, j int) bool {
+		return trks[i].quality > trks[j].quality
+	})
+	sorted := make([][]int, len(trks))
+	for i, trk := range trks {
+		sorted[i] = trk.hits
+	}
+	return labelTracks(sorted, len(hits), m.MinHits), nil
+}
+
+// candidates returns the unique candidate tracks with at least MinHits hits,
+// together with their quality.
+func (m *Merger) candidates(hits []trackml.Hit, tracks [][]int) []candidate {
+	var (
+		cands = make([]candidate, 0, len(tracks))
+		seen  = make(map[string]struct{}, len(tracks))
+		key   []byte
+	)
+	for _, trk := range tracks {
+		if len(trk) < m.MinHits {
+			continue
+		}
+		trk = append([]int(nil), trk...)
+		sort.Ints(trk)
+		key = key[:0]
+		for _, hit := range trk {
+			for i := uint(0); i < 64; i += 8 {
+				key = append(key, byte(hit>>i))
+			}
+		}
+		if _, dup := seen[string(key)]; dup {
+			continue
+		}
+		seen[string(key)] = struct{}{}
+		cands = append(cands, candidate{hits: trk, quality: m.quality(hits, trk)})
+	}
+	return cands
+}
+
+// quality returns the quality estimator of the track made of the provided hits.
+func (m *Merger) quality(hits []trackml.Hit, trk []int) float64 {
+	type layer struct {
+		vol, lay int
+	}
+	layers := make(map[layer]struct{}, len(trk))
+	for _, i := range trk {
+		layers[layer{hits[i].VolumeID, hits[i].LayerID}] = struct{}{}
+	}
+	q := m.WHits*float64(len(trk)) + m.WLayers*float64(len(layers))
+	chi2, ndf := m.chi2(hits, trk)
+	if ndf > 0 {
+		q -= m.WChi2 * chi2 / float64(ndf)
+	}
+	return q
+}
+
+// chi2 fits a helix through the provided hits and returns the chi2 and number
+// of degrees of freedom of the fit.
+func (m *Merger) chi2(hits []trackml.Hit, trk []int) (float64, int) {
+	n := len(trk)
+	if n < 4 {
+		return 0, 0
+	}
+	sel := make([]trackml.Hit, n)
+	for i, hit := range trk {
+		sel[i] = hits[hit]
+	}
+	hlx, err := m.Fitter.Fit(sel)
+	if err != nil {
+		return math.Inf(+1), 2*n - 5
+	}
+	return hlx.Chi2, hlx.NDF
+}
+
+// union returns the sorted union of the sorted slices a and b.
+func union(a, b []int) []int {
+	o := make([]int, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] < b[j]:
+			o = append(o, a[i])
+			i++
+		case a[i] > b[j]:
+			o = append(o, b[j])
+			j++
+		default:
+			o = append(o, a[i])
+			i++
+			j++
+		}
+	}
+	o = append(o, a[i:]...)
+	o = append(o, b[j:]...)
+	return o
+}
+
+// contains returns whether the sorted slice vs contains v.
+func contains(vs []int, v int) bool {
+	i := sort.SearchInts(vs, v)
+	return i < len(vs) && vs[i] == v
+}
@@ -24,6 +24,15 @@ type pcluster struct {
 
 // Predict clusters hits.
 func (pcl *pcluster) Predict(hits []trackml.Hit) ([]int, error) {
+	tracks, err := pcl.Find(hits)
+	if err != nil {
+		return nil, err
+	}
+	return labelTracks(tracks, len(hits), pcl.minHits), nil
+}
+
+// Find returns the candidate tracks, ordered by theta slice.
+func (pcl *pcluster) Find(hits []trackml.Hit) ([][]int, error) {
 	workers := make([]worker, pcl.nWorkers)
 	for i := range workers {
 		workers[i].tracks = make(map[int][][]int)
@@ -71,25 +80,7 @@ func (pcl *pcluster) Predict(hits []trackml.Hit) ([]int, error) {
 		}
 	}
 
-	trackID := 0
-	labels := make([]int, len(hits))
-	used := make(map[int]struct{}, len(hits))
-	for _, hits := range tracks[:] {
-		slice := make([]int, 0, len(hits))
-		for _, hit := range hits {
-			if _, dup := used[hit]; !dup {
-				slice = append(slice, hit)
-			}
-		}
-		if len(slice) >= pcl.minHits {
-			for _, v := range slice {
-				labels[v] = trackID
-				used[v] = struct{}{}
-			}
-			trackID++
-		}
-	}
-	return labels, nil
+	return tracks, nil
 }
 
 type worker struct {
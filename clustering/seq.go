@@ -22,6 +22,15 @@ type scluster struct {
 
 // Predict clusters hits.
 func (scl *scluster) Predict(hits []trackml.Hit) ([]int, error) {
+	tracks, err := scl.Find(hits)
+	if err != nil {
+		return nil, err
+	}
+	return labelTracks(tracks, len(hits), scl.minHits), nil
+}
+
+// Find returns the candidate tracks, ordered by theta slice.
+func (scl *scluster) Find(hits []trackml.Hit) ([][]int, error) {
 	h := hough.New(hits)
 
 	theta := make([]float64, scl.nbinsTheta)
@@ -32,23 +41,5 @@ func (scl *scluster) Predict(hits []trackml.Hit) ([]int, error) {
 		tracks = h.Calc(tracks, v, scl.nbinsR0Inv, scl.nbinsGamma, scl.minHits)
 	}
 
-	trackID := 0
-	labels := make([]int, len(hits))
-	used := make(map[int]struct{}, len(hits))
-	for _, hits := range tracks[:] {
-		slice := make([]int, 0, len(hits))
-		for _, hit := range hits {
-			if _, dup := used[hit]; !dup {
-				slice = append(slice, hit)
-			}
-		}
-		if len(slice) >= scl.minHits {
-			for _, v := range slice {
-				labels[v] = trackID
-				used[v] = struct{}{}
-			}
-			trackID++
-		}
-	}
-	return labels, nil
+	return tracks, nil
 }
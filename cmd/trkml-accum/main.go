@@ -0,0 +1,119 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// trkml-accum snapshots the Hough accumulator of an event for a theta slice.
+//
+// trkml-accum writes the heat map of the accumulator, with the hits of the
+// Monte-Carlo particles overlaid, and the snapshot in JSON and CSV.
+//
+// Usage:
+//
+//	$> trkml-accum [OPTIONS] <path-to-dataset> <evtid-prefix>
+//
+// Examples:
+//
+//	$> trkml-accum ./train_sample.zip event000001000
+//	$> trkml-accum -theta=1.2 -nbins-gamma=100 -o=slice ./train_sample.zip event000001000
+//
+// Options:
+//
+//	-nbins-gamma int
+//	  	number of gamma bins (default 500)
+//	-nbins-r0inv int
+//	  	number of r0inv bins (default 200)
+//	-o string
+//	  	prefix of the output files (default "accum")
+//	-theta float
+//	  	theta slice of the accumulator
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/sbinet/go-trackml"
+	"github.com/sbinet/go-trackml/display"
+	"github.com/sbinet/go-trackml/hough"
+	"gonum.org/v1/plot/vg"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("trkml-accum: ")
+
+	nbinsGamma := flag.Int("nbins-gamma", 500, "number of gamma bins")
+	nbinsR0Inv := flag.Int("nbins-r0inv", 200, "number of r0inv bins")
+	oname := flag.String("o", "accum", "prefix of the output files")
+	theta := flag.Float64("theta", 0, "theta slice of the accumulator")
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, `trkml-accum snapshots the Hough accumulator for a theta slice.
+
+Usage:
+
+  $> trkml-accum [OPTIONS] <path-to-dataset> <evtid-prefix>
+
+Examples:
+
+  $> trkml-accum ./train_sample.zip event000001000
+  $> trkml-accum -theta=1.2 -nbins-gamma=100 -o=slice ./train_sample.zip event000001000
+
+Options:
+
+`)
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+
+	path := flag.Arg(0)
+	if path == "" {
+		flag.Usage()
+		log.Fatalf("missing path to event dataset")
+	}
+	evtid := flag.Arg(1)
+	if evtid == "" {
+		flag.Usage()
+		log.Fatalf("missing event ID within dataset")
+	}
+
+	evt, err := trackml.ReadMcEvent(path, evtid)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	s := hough.New(evt.Hits).Snapshot(*theta, *nbinsR0Inv, *nbinsGamma)
+
+	p, err := display.Hough(s, evt)
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = p.Save(20*vg.Centimeter, 20*vg.Centimeter, *oname+".png")
+	if err != nil {
+		log.Fatalf("could not save heat map: %+v", err)
+	}
+
+	for _, v := range []struct {
+		ext   string
+		write func(f *os.File) error
+	}{
+		{".json", func(f *os.File) error { return s.WriteJSON(f) }},
+		{".csv", func(f *os.File) error { return s.WriteCSV(f) }},
+	} {
+		f, err := os.Create(*oname + v.ext)
+		if err != nil {
+			log.Fatalf("could not create output file: %v", err)
+		}
+		err = v.write(f)
+		if err != nil {
+			log.Fatalf("could not write snapshot: %+v", err)
+		}
+		err = f.Close()
+		if err != nil {
+			log.Fatalf("could not close output file: %v", err)
+		}
+	}
+}
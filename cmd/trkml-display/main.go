@@ -0,0 +1,133 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// trkml-display draws the hits of an event in the x-y and r-z projections.
+//
+// Hits are coloured by Monte-Carlo particle or, with -pred, by track as
+// predicted by the Hough transform classifier.
+// The format of the output file (png, svg, pdf, ...) is deduced from its
+// extension.
+//
+// Usage:
+//
+//	$> trkml-display [OPTIONS] <path-to-dataset> <evtid-prefix>
+//
+// Examples:
+//
+//	$> trkml-display ./train_sample.zip event000001000
+//	$> trkml-display -o=evt.svg -detector=detectors.csv ./train_sample.zip event000001000
+//	$> trkml-display -pred -highlight -ncpus=-1 ./train_sample.zip event000001000
+//
+// Options:
+//
+//	-detector string
+//	  	path to the detectors.csv file, to outline detector layers
+//	-highlight
+//	  	highlight fake and split predicted tracks
+//	-ncpus int
+//	  	number of goroutines to use for the prediction (default 1)
+//	-o string
+//	  	path to the output image file (default "display.png")
+//	-pred
+//	  	colour hits by predicted track instead of Monte-Carlo particle
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+
+	"github.com/sbinet/go-trackml"
+	"github.com/sbinet/go-trackml/clustering"
+	"github.com/sbinet/go-trackml/display"
+	"gonum.org/v1/plot/vg"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("trkml-display: ")
+
+	dname := flag.String("detector", "", "path to the detectors.csv file, to outline detector layers")
+	highlight := flag.Bool("highlight", false, "highlight fake and split predicted tracks")
+	ncpus := flag.Int("ncpus", 1, "number of goroutines to use for the prediction")
+	oname := flag.String("o", "display.png", "path to the output image file")
+	pred := flag.Bool("pred", false, "colour hits by predicted track instead of Monte-Carlo particle")
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, `trkml-display draws the hits of an event.
+
+Usage:
+
+  $> trkml-display [OPTIONS] <path-to-dataset> <evtid-prefix>
+
+Examples:
+
+  $> trkml-display ./train_sample.zip event000001000
+  $> trkml-display -o=evt.svg -detector=detectors.csv ./train_sample.zip event000001000
+  $> trkml-display -pred -highlight -ncpus=-1 ./train_sample.zip event000001000
+
+Options:
+
+`)
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+
+	if *ncpus <= 0 {
+		*ncpus = runtime.NumCPU() + 1
+	}
+
+	path := flag.Arg(0)
+	if path == "" {
+		flag.Usage()
+		log.Fatalf("missing path to event dataset")
+	}
+	evtid := flag.Arg(1)
+	if evtid == "" {
+		flag.Usage()
+		log.Fatalf("missing event ID within dataset")
+	}
+
+	if *highlight && !*pred {
+		log.Fatalf("-highlight requires -pred")
+	}
+
+	evt, err := trackml.ReadMcEvent(path, evtid)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var labels []int
+	if *pred {
+		const (
+			nbinsR0Inv = 200
+			nbinsGamma = 500
+			nbinsTheta = 500
+			minHits    = 9
+		)
+		model := clustering.New(*ncpus, nbinsR0Inv, nbinsGamma, nbinsTheta, minHits)
+		labels, err = model.Predict(evt.Hits)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("score for event %v: %v", evt.ID, trackml.Score(evt, labels))
+	}
+
+	d := display.New(evt, labels)
+	d.Highlight = *highlight
+	if *dname != "" {
+		d.Detector, err = trackml.ReadDetector(*dname)
+		if err != nil {
+			log.Fatalf("could not read detector: %+v", err)
+		}
+	}
+
+	err = d.Save(40*vg.Centimeter, 20*vg.Centimeter, *oname)
+	if err != nil {
+		log.Fatalf("could not save display: %+v", err)
+	}
+}
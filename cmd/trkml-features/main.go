@@ -0,0 +1,142 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// trkml-features extracts per-hit features from the events of a dataset and
+// saves them, with the particle ID of each hit, in one CSV file per event.
+//
+// Usage:
+//
+//	$> trkml-features [OPTIONS] <path-to-dataset>
+//
+// Examples:
+//
+//	$> trkml-features -list
+//	$> trkml-features -f=r,phi,z,ncells,charge -o=features ./train_sample.zip
+//	$> trkml-features -detector=detectors.csv -f=r,phi,cos_incidence ./train_sample.zip
+//
+// Options:
+//
+//	-detector string
+//	  	path to the detectors.csv file, for geometry-dependent features
+//	-f string
+//	  	comma-separated list of features (default "r,phi,z,eta,ncells,charge")
+//	-list
+//	  	list the available features
+//	-n int
+//	  	number of events to process (-1 for all) (default -1)
+//	-o string
+//	  	path to the output directory (default "features")
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sbinet/go-trackml"
+	"github.com/sbinet/go-trackml/features"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("trkml-features: ")
+
+	dname := flag.String("detector", "", "path to the detectors.csv file, for geometry-dependent features")
+	fnames := flag.String("f", "r,phi,z,eta,ncells,charge", "comma-separated list of features")
+	list := flag.Bool("list", false, "list the available features")
+	nevts := flag.Int("n", -1, "number of events to process (-1 for all)")
+	odir := flag.String("o", "features", "path to the output directory")
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, `trkml-features extracts per-hit features and saves them as CSV files.
+
+Usage:
+
+  $> trkml-features [OPTIONS] <path-to-dataset>
+
+Examples:
+
+  $> trkml-features -list
+  $> trkml-features -f=r,phi,z,ncells,charge -o=features ./train_sample.zip
+  $> trkml-features -detector=detectors.csv -f=r,phi,cos_incidence ./train_sample.zip
+
+Options:
+
+`)
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+
+	if *list {
+		for _, name := range features.Names() {
+			f, _ := features.Lookup(name)
+			fmt.Printf("%-16s %s\n", name, f.Doc)
+		}
+		return
+	}
+
+	path := flag.Arg(0)
+	if path == "" {
+		flag.Usage()
+		log.Fatalf("missing path to event dataset")
+	}
+
+	var det *trackml.Detector
+	if *dname != "" {
+		var err error
+		det, err = trackml.ReadDetector(*dname)
+		if err != nil {
+			log.Fatalf("could not read detector: %+v", err)
+		}
+	}
+
+	ex, err := features.New(det, strings.Split(*fnames, ",")...)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = os.MkdirAll(*odir, 0755)
+	if err != nil {
+		log.Fatalf("could not create output directory: %v", err)
+	}
+
+	ds, err := trackml.NewDataset(path, 0, *nevts, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer ds.Close()
+
+	for ds.Next() {
+		evt := ds.Event()
+		oname := filepath.Join(*odir, fmt.Sprintf("event%09d-features.csv", evt.ID))
+		err = write(oname, evt, ex)
+		if err != nil {
+			log.Fatalf("could not write features of event %d: %+v", evt.ID, err)
+		}
+		log.Printf("event %d: hits=%d", evt.ID, len(evt.Hits))
+		evt.Delete()
+	}
+	if err := ds.Err(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func write(fname string, evt trackml.Event, ex *features.Extractor) error {
+	f, err := os.Create(fname)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	err = features.WriteCSV(f, evt, ex.Names(), ex.Extract(evt))
+	if err != nil {
+		return err
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,145 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// trkml-graph builds the hit graphs of the events of a dataset and saves them
+// in the NumPy compressed data format, one file per event.
+//
+// Each file holds the arrays hit_id, node_features, edge_senders,
+// edge_receivers, edge_features and edge_labels.
+//
+// Usage:
+//
+//	$> trkml-graph [OPTIONS] <path-to-dataset>
+//
+// Examples:
+//
+//	$> trkml-layers -o=layers.json ./train_sample.zip
+//	$> trkml-graph -layers=layers.json -o=graphs ./train_sample.zip
+//
+// Options:
+//
+//	-layers string
+//	  	path to the layer graph file (default "layers.json")
+//	-n int
+//	  	number of events to process (-1 for all) (default -1)
+//	-o string
+//	  	path to the output directory (default "graphs")
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/sbinet/go-trackml"
+	"github.com/sbinet/go-trackml/hitgraph"
+	"github.com/sbinet/go-trackml/layers"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("trkml-graph: ")
+
+	lname := flag.String("layers", "layers.json", "path to the layer graph file")
+	nevts := flag.Int("n", -1, "number of events to process (-1 for all)")
+	odir := flag.String("o", "graphs", "path to the output directory")
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, `trkml-graph builds hit graphs and saves them as NPZ files.
+
+Usage:
+
+  $> trkml-graph [OPTIONS] <path-to-dataset>
+
+Examples:
+
+  $> trkml-layers -o=layers.json ./train_sample.zip
+  $> trkml-graph -layers=layers.json -o=graphs ./train_sample.zip
+
+Options:
+
+`)
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+
+	path := flag.Arg(0)
+	if path == "" {
+		flag.Usage()
+		log.Fatalf("missing path to event dataset")
+	}
+
+	f, err := os.Open(*lname)
+	if err != nil {
+		log.Fatalf("could not open layer graph file: %v", err)
+	}
+	defer f.Close()
+
+	lg, err := layers.Load(f)
+	if err != nil {
+		log.Fatalf("could not load layer graph: %+v", err)
+	}
+
+	err = os.MkdirAll(*odir, 0755)
+	if err != nil {
+		log.Fatalf("could not create output directory: %v", err)
+	}
+
+	ds, err := trackml.NewDataset(path, 0, *nevts, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer ds.Close()
+
+	var (
+		b   = hitgraph.New(lg)
+		tot hitgraph.Stats
+	)
+	for ds.Next() {
+		evt := ds.Event()
+		g := b.Build(evt)
+		st := hitgraph.Evaluate(evt, g)
+		tot.Add(st)
+
+		oname := filepath.Join(*odir, fmt.Sprintf("event%09d.npz", evt.ID))
+		err = write(oname, g)
+		if err != nil {
+			log.Fatalf("could not write graph of event %d: %+v", evt.ID, err)
+		}
+
+		log.Printf(
+			"event %d: nodes=%d edges=%d efficiency=%.4f purity=%.4f",
+			evt.ID, len(g.HitIDs), st.Edges, st.Efficiency(), st.Purity(),
+		)
+		evt.Delete()
+	}
+	if err := ds.Err(); err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf(
+		"total: edges=%d efficiency=%.4f (%d/%d) purity=%.4f (%d/%d)",
+		tot.Edges,
+		tot.Efficiency(), tot.Found, tot.Segments,
+		tot.Purity(), tot.True, tot.Edges,
+	)
+}
+
+func write(fname string, g hitgraph.Graph) error {
+	f, err := os.Create(fname)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	err = g.WriteNPZ(f)
+	if err != nil {
+		return err
+	}
+
+	return f.Close()
+}
@@ -16,9 +16,14 @@
 //   $> trkml-hough -npcus=+1 ./example_standard/dataset event000000200
 //   $> trkml-hough -npcus=-1 ./example_standard/dataset event000000200
 //   $> trkml-hough -npcus=-1 ./train_sample.zip event000001000
+//   $> trkml-hough -npcus=8 -events=4 ./train_sample.zip event000001000
 //
 // Options:
 //
+//   -events int
+//     	number of events to process concurrently, sharing the -ncpus goroutines (default 1)
+//   -merge
+//     	resolve overlapping tracks by quality instead of theta order
 //   -ncpus int
 //     	number of goroutines to use for the prediction (default 1)
 //   -prof-cpu
@@ -40,7 +45,7 @@ import (
 	"github.com/pkg/profile"
 	"github.com/sbinet/go-trackml"
 	"github.com/sbinet/go-trackml/clustering"
-	"gonum.org/v1/gonum/stat"
+	"github.com/sbinet/go-trackml/eval"
 )
 
 func main() {
@@ -48,6 +53,8 @@ func main() {
 	log.SetPrefix("trkml-hough: ")
 
 	ncpus := flag.Int("ncpus", 1, "number of goroutines to use for the prediction")
+	nevts := flag.Int("events", 1, "number of events to process concurrently, sharing the -ncpus goroutines")
+	flagMerge := flag.Bool("merge", false, "resolve overlapping tracks by quality instead of theta order")
 	flagSubmit := flag.Bool("submit", false, "create a submission file")
 	profCPU := flag.Bool("prof-cpu", false, "enable CPU profiling")
 	profMEM := flag.Bool("prof-mem", false, "enable MEM profiling")
@@ -65,6 +72,7 @@ Examples:
   $> trkml-hough -npcus=+1 ./example_standard/dataset event000000200
   $> trkml-hough -npcus=-1 ./example_standard/dataset event000000200
   $> trkml-hough -npcus=-1 ./train_sample.zip event000001000
+  $> trkml-hough -npcus=8 -events=4 ./train_sample.zip event000001000
 
 Options:
 
@@ -113,7 +121,18 @@ Options:
 		nbinsTheta = 500
 	)
 
-	model := clustering.New(*ncpus, nbinsR0Inv, nbinsGamma, nbinsTheta, 9)
+	const minHits = 9
+
+	newModel := func(n int) clustering.Classifier {
+		if *flagMerge {
+			return clustering.NewPipeline(
+				clustering.NewFinder(n, nbinsR0Inv, nbinsGamma, nbinsTheta, minHits),
+				clustering.NewMerger(minHits),
+			)
+		}
+		return clustering.New(n, nbinsR0Inv, nbinsGamma, nbinsTheta, minHits)
+	}
+	model := newModel(*ncpus)
 
 	var labels []int
 	labels, err = model.Predict(evt.Hits)
@@ -125,31 +144,20 @@ Options:
 	log.Printf("score for event %v: %v", evt.ID, score)
 
 	log.Printf("loading the whole dataset %q...", path)
-	var scores []float64
-	ds, err := trackml.NewDataset(path, 0, 5, nil)
+	run := eval.New(newModel, *ncpus)
+	run.Events = *nevts
+	res, sum, err := run.Run(path, 0, 5)
 	if err != nil {
 		log.Fatal(err)
 	}
-	for ds.Next() {
-		evt = ds.Event()
-
-		var labels []int
-		labels, err = model.Predict(evt.Hits)
-		if err != nil {
-			log.Fatal(err)
-		}
-
-		score := trackml.Score(evt, labels)
-		log.Printf("score for event %v: %v", evt.ID, score)
-		scores = append(scores, score)
-		evt.Delete()
-	}
-	if err := ds.Err(); err != nil {
-		log.Fatal(err)
+	for _, r := range res {
+		log.Printf("score for event %v: %v (read: %v, predict: %v)", r.Event, r.Score, r.Read, r.Predict)
 	}
-	log.Printf("loading the whole dataset %q... [done]", path)
+	log.Printf("loading the whole dataset %q... [done] (%v)", path, sum.Elapsed)
 
-	log.Printf("mean score: %v", stat.Mean(scores, nil))
+	log.Printf("mean score:   %v", sum.Mean)
+	log.Printf("median score: %v", sum.Median)
+	log.Printf("std-dev:      %v", sum.StdDev)
 
 	if *flagSubmit {
 		sub, err := trackml.NewSubmission()
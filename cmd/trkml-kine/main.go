@@ -0,0 +1,258 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// trkml-kine estimates the kinematics of the tracks reconstructed with a Hough
+// transform, and compares them with the ones of the matched truth particles.
+//
+// Usage:
+//
+//	$> trkml-kine [OPTIONS] <path-to-dataset> <evtid-prefix>
+//
+// Examples:
+//
+//	$> trkml-kine ./example_standard/dataset event000000200
+//	$> trkml-kine -bfield=2 -o=kine ./train_sample.zip event000001000
+//
+// Options:
+//
+//	-all
+//	  	use all the reconstructed tracks, not only the good ones
+//	-bfield float
+//	  	magnetic field along the beam axis (Tesla) (default 2)
+//	-ncpus int
+//	  	number of goroutines to use for the prediction (default 1)
+//	-o string
+//	  	prefix of the output plot files (default "kine")
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image/color"
+	"log"
+	"math"
+	"os"
+	"runtime"
+
+	"github.com/sbinet/go-trackml"
+	"github.com/sbinet/go-trackml/clustering"
+	"github.com/sbinet/go-trackml/fit"
+	"gonum.org/v1/gonum/stat"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("trkml-kine: ")
+
+	ncpus := flag.Int("ncpus", 1, "number of goroutines to use for the prediction")
+	bfield := flag.Float64("bfield", 2, "magnetic field along the beam axis (Tesla)")
+	oname := flag.String("o", "kine", "prefix of the output plot files")
+	all := flag.Bool("all", false, "use all the reconstructed tracks, not only the good ones")
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, `trkml-kine compares reconstructed and true track kinematics.
+
+Usage:
+
+  $> trkml-kine [OPTIONS] <path-to-dataset> <evtid-prefix>
+
+Examples:
+
+  $> trkml-kine ./example_standard/dataset event000000200
+  $> trkml-kine -bfield=2 -o=kine ./train_sample.zip event000001000
+
+Options:
+
+`)
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+
+	if *ncpus <= 0 {
+		*ncpus = runtime.NumCPU() + 1
+	}
+
+	path := flag.Arg(0)
+	if path == "" {
+		flag.Usage()
+		log.Fatalf("missing path to event dataset")
+	}
+	evtid := flag.Arg(1)
+	if evtid == "" {
+		flag.Usage()
+		log.Fatalf("missing event ID within dataset")
+	}
+
+	log.Printf("loading [%s from %s]...", evtid, path)
+	evt, err := trackml.ReadMcEvent(path, evtid)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("loading [%s from %s]... [done]", evtid, path)
+
+	const (
+		nbinsR0Inv = 200
+		nbinsGamma = 500
+		nbinsTheta = 500
+		minHits    = 9
+	)
+
+	model := clustering.New(*ncpus, nbinsR0Inv, nbinsGamma, nbinsTheta, minHits)
+	labels, err := model.Predict(evt.Hits)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	f := fit.NewFitter()
+	f.BField = *bfield
+
+	var ms []fit.Match
+	for _, m := range f.MatchKinematics(evt, labels) {
+		if !*all && !m.Good() {
+			continue
+		}
+		ms = append(ms, m)
+	}
+	log.Printf("matched tracks: %d", len(ms))
+	if len(ms) == 0 {
+		log.Fatalf("no matched track")
+	}
+
+	var (
+		dpt  = make([]float64, len(ms))
+		deta = make([]float64, len(ms))
+		dphi = make([]float64, len(ms))
+		qmis = 0
+	)
+	for i, m := range ms {
+		dpt[i] = (m.Reco.Pt - m.True.Pt) / m.True.Pt
+		deta[i] = m.Reco.Eta - m.True.Eta
+		dphi[i] = math.Remainder(m.Reco.Phi-m.True.Phi, 2*math.Pi)
+		if m.Reco.Q != m.True.Q {
+			qmis++
+		}
+	}
+
+	for _, v := range []struct {
+		name string
+		vs   []float64
+	}{
+		{"(pt-pt_true)/pt_true", dpt},
+		{"eta-eta_true", deta},
+		{"phi-phi_true", dphi},
+	} {
+		mean, std := stat.MeanStdDev(v.vs, nil)
+		log.Printf("%-22s mean=%+e std=%e", v.name, mean, std)
+	}
+	log.Printf("charge mis-identification: %v (%d/%d)", float64(qmis)/float64(len(ms)), qmis, len(ms))
+
+	for _, v := range []struct {
+		name  string
+		title string
+		vs    []float64
+		min   float64
+		max   float64
+	}{
+		{"pt", "(p_T - p_T^{true}) / p_T^{true}", dpt, -1, +1},
+		{"eta", "η - η^{true}", deta, -0.1, +0.1},
+		{"phi", "φ - φ^{true}", dphi, -0.1, +0.1},
+	} {
+		err := plotResolution(*oname+"-"+v.name+".png", v.title, v.vs, v.min, v.max)
+		if err != nil {
+			log.Fatalf("could not plot %s resolution: %+v", v.name, err)
+		}
+	}
+
+	err = plotChargeMisID(*oname+"-charge.png", ms)
+	if err != nil {
+		log.Fatalf("could not plot charge mis-identification: %+v", err)
+	}
+}
+
+func plotResolution(fname, title string, vs []float64, min, max float64) error {
+	const nbins = 100
+	var (
+		width = (max - min) / nbins
+		bins  = make([]plotter.HistogramBin, nbins)
+	)
+	for i := range bins {
+		bins[i].Min = min + float64(i)*width
+		bins[i].Max = bins[i].Min + width
+	}
+	for _, v := range vs {
+		i := int((v - min) / width)
+		if i < 0 || i >= nbins {
+			continue
+		}
+		bins[i].Weight++
+	}
+
+	p := plot.New()
+	p.X.Label.Text = title
+	p.Y.Label.Text = "tracks"
+
+	h := &plotter.Histogram{
+		Bins:      bins,
+		Width:     width,
+		FillColor: color.Gray{Y: 200},
+		LineStyle: plotter.DefaultLineStyle,
+	}
+	p.Add(h)
+
+	return p.Save(12*vg.Centimeter, 9*vg.Centimeter, fname)
+}
+
+func plotChargeMisID(fname string, ms []fit.Match) error {
+	edges := []float64{0.1, 0.3, 0.5, 1, 2, 5, 10, 100}
+	var (
+		ntot = make([]float64, len(edges)-1)
+		nmis = make([]float64, len(edges)-1)
+	)
+	for _, m := range ms {
+		for i := range ntot {
+			if edges[i] <= m.True.Pt && m.True.Pt < edges[i+1] {
+				ntot[i]++
+				if m.Reco.Q != m.True.Q {
+					nmis[i]++
+				}
+				break
+			}
+		}
+	}
+
+	var pts plotter.XYs
+	for i := range ntot {
+		if ntot[i] == 0 {
+			continue
+		}
+		pts = append(pts, plotter.XY{
+			X: math.Sqrt(edges[i] * edges[i+1]),
+			Y: nmis[i] / ntot[i],
+		})
+	}
+
+	p := plot.New()
+	p.X.Label.Text = "p_T^{true} (GeV/c)"
+	p.X.Scale = plot.LogScale{}
+	p.X.Tick.Marker = plot.LogTicks{}
+	p.Y.Label.Text = "charge mis-identification rate"
+
+	if len(pts) > 0 {
+		s, err := plotter.NewScatter(pts)
+		if err != nil {
+			return err
+		}
+		l, err := plotter.NewLine(pts)
+		if err != nil {
+			return err
+		}
+		p.Add(s, l)
+	}
+
+	return p.Save(12*vg.Centimeter, 9*vg.Centimeter, fname)
+}
@@ -0,0 +1,104 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// trkml-layers learns which detector layers are consecutive along the
+// trajectories of Monte-Carlo particles, and saves the resulting layer graph.
+//
+// Usage:
+//
+//	$> trkml-layers [OPTIONS] <path-to-dataset>
+//
+// Examples:
+//
+//	$> trkml-layers ./train_sample.zip
+//	$> trkml-layers -n=100 -min-freq=0.001 -o=layers.json ./train_100_events
+//
+// Options:
+//
+//	-min-freq float
+//	  	minimum frequency of the transitions to keep
+//	-n int
+//	  	number of events to use (-1 for all) (default -1)
+//	-o string
+//	  	path to the output layer graph file (default "layers.json")
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/sbinet/go-trackml"
+	"github.com/sbinet/go-trackml/layers"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("trkml-layers: ")
+
+	nevts := flag.Int("n", -1, "number of events to use (-1 for all)")
+	minFreq := flag.Float64("min-freq", 0, "minimum frequency of the transitions to keep")
+	oname := flag.String("o", "layers.json", "path to the output layer graph file")
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, `trkml-layers learns the layer graph from Monte-Carlo particles.
+
+Usage:
+
+  $> trkml-layers [OPTIONS] <path-to-dataset>
+
+Examples:
+
+  $> trkml-layers ./train_sample.zip
+  $> trkml-layers -n=100 -min-freq=0.001 -o=layers.json ./train_100_events
+
+Options:
+
+`)
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+
+	path := flag.Arg(0)
+	if path == "" {
+		flag.Usage()
+		log.Fatalf("missing path to event dataset")
+	}
+
+	log.Printf("learning layer graph from %q...", path)
+	ds, err := trackml.NewDataset(path, 0, *nevts, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer ds.Close()
+
+	g, err := layers.Learn(&ds)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("learning layer graph from %q... [done]", path)
+
+	if *minFreq > 0 {
+		g = g.Prune(*minFreq)
+	}
+	log.Printf("transitions: %d", len(g.Transitions()))
+
+	f, err := os.Create(*oname)
+	if err != nil {
+		log.Fatalf("could not create output file: %v", err)
+	}
+	defer f.Close()
+
+	err = g.Save(f)
+	if err != nil {
+		log.Fatalf("could not save layer graph: %+v", err)
+	}
+
+	err = f.Close()
+	if err != nil {
+		log.Fatalf("could not close output file: %v", err)
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// trkml-pairs generates balanced samples of labeled hit pairs from the events
+// of a dataset, and streams them to CSV files of bounded size.
+//
+// Usage:
+//
+//	$> trkml-pairs [OPTIONS] <path-to-dataset>
+//
+// Examples:
+//
+//	$> trkml-layers -o=layers.json ./train_sample.zip
+//	$> trkml-pairs -layers=layers.json -o=pairs ./train_sample.zip
+//	$> trkml-pairs -detector=detectors.csv -neg=3 -chunk=100000 ./train_100_events
+//
+// Options:
+//
+//	-chunk int
+//	  	maximum number of pairs per output file (default 1000000)
+//	-detector string
+//	  	path to the detectors.csv file, for the cell direction features
+//	-layers string
+//	  	path to the layer graph file (default "layers.json")
+//	-n int
+//	  	number of events to process (-1 for all) (default -1)
+//	-neg float
+//	  	number of negative pairs per positive pair (default 1)
+//	-o string
+//	  	path to the output directory (default "pairs")
+//	-seed int
+//	  	seed of the random number generator (default 1234)
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/sbinet/go-trackml"
+	"github.com/sbinet/go-trackml/layers"
+	"github.com/sbinet/go-trackml/pairs"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("trkml-pairs: ")
+
+	chunk := flag.Int("chunk", 1000000, "maximum number of pairs per output file")
+	dname := flag.String("detector", "", "path to the detectors.csv file, for the cell direction features")
+	lname := flag.String("layers", "layers.json", "path to the layer graph file")
+	nevts := flag.Int("n", -1, "number of events to process (-1 for all)")
+	neg := flag.Float64("neg", 1, "number of negative pairs per positive pair")
+	odir := flag.String("o", "pairs", "path to the output directory")
+	seed := flag.Int64("seed", 1234, "seed of the random number generator")
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, `trkml-pairs generates labeled hit pairs and saves them as CSV files.
+
+Usage:
+
+  $> trkml-pairs [OPTIONS] <path-to-dataset>
+
+Examples:
+
+  $> trkml-layers -o=layers.json ./train_sample.zip
+  $> trkml-pairs -layers=layers.json -o=pairs ./train_sample.zip
+  $> trkml-pairs -detector=detectors.csv -neg=3 -chunk=100000 ./train_100_events
+
+Options:
+
+`)
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+
+	path := flag.Arg(0)
+	if path == "" {
+		flag.Usage()
+		log.Fatalf("missing path to event dataset")
+	}
+
+	if *chunk <= 0 {
+		log.Fatalf("invalid chunk size %d", *chunk)
+	}
+
+	f, err := os.Open(*lname)
+	if err != nil {
+		log.Fatalf("could not open layer graph file: %v", err)
+	}
+	defer f.Close()
+
+	lg, err := layers.Load(f)
+	if err != nil {
+		log.Fatalf("could not load layer graph: %+v", err)
+	}
+
+	var det *trackml.Detector
+	if *dname != "" {
+		det, err = trackml.ReadDetector(*dname)
+		if err != nil {
+			log.Fatalf("could not read detector: %+v", err)
+		}
+	}
+
+	err = os.MkdirAll(*odir, 0755)
+	if err != nil {
+		log.Fatalf("could not create output directory: %v", err)
+	}
+
+	ds, err := trackml.NewDataset(path, 0, *nevts, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer ds.Close()
+
+	s := pairs.New(lg, det, *seed)
+	s.NegRatio = *neg
+
+	w, err := pairs.NewWriter(*odir, "pairs", *chunk)
+	if err != nil {
+		log.Fatalf("could not create pairs writer: %+v", err)
+	}
+	defer w.Close()
+
+	var npos, ntot int
+	for ds.Next() {
+		evt := ds.Event()
+		ps := s.Sample(evt)
+		err = w.Write(ps)
+		if err != nil {
+			log.Fatalf("could not write pairs of event %d: %+v", evt.ID, err)
+		}
+
+		n := 0
+		for _, p := range ps {
+			if p.Label {
+				n++
+			}
+		}
+		npos += n
+		ntot += len(ps)
+		log.Printf("event %d: pairs=%d positive=%d", evt.ID, len(ps), n)
+		evt.Delete()
+	}
+	if err := ds.Err(); err != nil {
+		log.Fatal(err)
+	}
+
+	err = w.Close()
+	if err != nil {
+		log.Fatalf("could not close pairs file: %+v", err)
+	}
+	log.Printf("total: pairs=%d positive=%d files=%d", ntot, npos, w.Chunks())
+}
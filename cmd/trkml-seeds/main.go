@@ -0,0 +1,112 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// trkml-seeds builds doublet and triplet seeds for an event and reports their
+// efficiency and purity.
+//
+// Usage:
+//
+//	$> trkml-seeds [OPTIONS] <path-to-dataset> <evtid-prefix>
+//
+// Examples:
+//
+//	$> trkml-layers -o=layers.json ./train_sample.zip
+//	$> trkml-seeds -layers=layers.json ./train_sample.zip event000001000
+//
+// Options:
+//
+//	-layers string
+//	  	path to the layer graph file (default "layers.json")
+//	-min-hits int
+//	  	minimum number of hits of reconstructable particles (default 3)
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/sbinet/go-trackml"
+	"github.com/sbinet/go-trackml/layers"
+	"github.com/sbinet/go-trackml/seeding"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("trkml-seeds: ")
+
+	lname := flag.String("layers", "layers.json", "path to the layer graph file")
+	minHits := flag.Int("min-hits", 3, "minimum number of hits of reconstructable particles")
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, `trkml-seeds builds seeds and reports their efficiency and purity.
+
+Usage:
+
+  $> trkml-seeds [OPTIONS] <path-to-dataset> <evtid-prefix>
+
+Examples:
+
+  $> trkml-layers -o=layers.json ./train_sample.zip
+  $> trkml-seeds -layers=layers.json ./train_sample.zip event000001000
+
+Options:
+
+`)
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+
+	path := flag.Arg(0)
+	if path == "" {
+		flag.Usage()
+		log.Fatalf("missing path to event dataset")
+	}
+	evtid := flag.Arg(1)
+	if evtid == "" {
+		flag.Usage()
+		log.Fatalf("missing event ID within dataset")
+	}
+
+	f, err := os.Open(*lname)
+	if err != nil {
+		log.Fatalf("could not open layer graph file: %v", err)
+	}
+	defer f.Close()
+
+	g, err := layers.Load(f)
+	if err != nil {
+		log.Fatalf("could not load layer graph: %+v", err)
+	}
+
+	evt, err := trackml.ReadMcEvent(path, evtid)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	s := seeding.New(g)
+	for _, v := range []struct {
+		name  string
+		build func([]trackml.Hit) []seeding.Seed
+	}{
+		{"doublets", s.Doublets},
+		{"triplets", s.Triplets},
+	} {
+		start := time.Now()
+		seeds := v.build(evt.Hits)
+		delta := time.Since(start)
+
+		st := seeding.Evaluate(evt, seeds, *minHits)
+		log.Printf(
+			"%s: seeds=%d efficiency=%.4f (%d/%d) purity=%.4f (%d/%d) time=%v",
+			v.name, st.Seeds,
+			st.Efficiency(), st.Found, st.Particles,
+			st.Purity(), st.True, st.Seeds,
+			delta,
+		)
+	}
+}
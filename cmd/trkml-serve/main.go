@@ -0,0 +1,108 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// trkml-serve serves a dataset of events over a local HTTP server.
+//
+// trkml-serve lists the events of the dataset and, for each event, shows
+// statistics about its hits and particles together with its x-y and r-z
+// displays.
+// With -sub, the predictions of a submission file are overlaid and the
+// score of each predicted track is detailed.
+//
+// Usage:
+//
+//	$> trkml-serve [OPTIONS] <path-to-dataset>
+//
+// Examples:
+//
+//	$> trkml-serve ./train_sample.zip
+//	$> trkml-serve -addr=localhost:8888 -detector=detectors.csv ./train_sample.zip
+//	$> trkml-serve -sub=submission.csv.gz ./train_sample.zip
+//
+// Options:
+//
+//	-addr string
+//	  	address to listen on (default "localhost:8080")
+//	-detector string
+//	  	path to the detectors.csv file, to outline detector layers
+//	-sub string
+//	  	path to a submission file with predictions to overlay
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/sbinet/go-trackml"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("trkml-serve: ")
+
+	addr := flag.String("addr", "localhost:8080", "address to listen on")
+	dname := flag.String("detector", "", "path to the detectors.csv file, to outline detector layers")
+	sname := flag.String("sub", "", "path to a submission file with predictions to overlay")
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, `trkml-serve serves a dataset of events over a local HTTP server.
+
+Usage:
+
+  $> trkml-serve [OPTIONS] <path-to-dataset>
+
+Examples:
+
+  $> trkml-serve ./train_sample.zip
+  $> trkml-serve -addr=localhost:8888 -detector=detectors.csv ./train_sample.zip
+  $> trkml-serve -sub=submission.csv.gz ./train_sample.zip
+
+Options:
+
+`)
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+
+	path := flag.Arg(0)
+	if path == "" {
+		flag.Usage()
+		log.Fatalf("missing path to event dataset")
+	}
+
+	var (
+		det   *trackml.Detector
+		preds trackml.Predictions
+		err   error
+	)
+	if *dname != "" {
+		det, err = trackml.ReadDetector(*dname)
+		if err != nil {
+			log.Fatalf("could not read detector: %+v", err)
+		}
+	}
+
+	if *sname != "" {
+		preds, err = trackml.ReadSubmission(*sname)
+		if err != nil {
+			log.Fatalf("could not read submission: %+v", err)
+		}
+	}
+
+	srv, err := newServer(path)
+	if err != nil {
+		log.Fatalf("could not open dataset: %+v", err)
+	}
+	srv.det = det
+	srv.preds = preds
+
+	log.Printf("serving %d events from %q on http://%s", len(srv.names), path, *addr)
+	err = http.ListenAndServe(*addr, srv)
+	srv.Close()
+	log.Fatal(err)
+}
@@ -0,0 +1,246 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"html/template"
+	"log"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/sbinet/go-trackml"
+	"github.com/sbinet/go-trackml/display"
+	"gonum.org/v1/plot/vg"
+)
+
+// server serves the events of a dataset.
+type server struct {
+	path  string
+	ds    trackml.Dataset
+	names []string // IDs of the events of the dataset
+
+	det   *trackml.Detector   // detector to outline, if any
+	preds trackml.Predictions // predictions to overlay, if any
+
+	mux *http.ServeMux
+
+	// mu protects the cache of the last event read from the dataset.
+	// The cached event is shared by concurrent requests: handlers only read
+	// it, as do trackml.Score, trackml.MatchTracks and the display package.
+	mu   sync.Mutex
+	name string        // name of the last event read from the dataset
+	evt  trackml.Event // last event read from the dataset
+}
+
+func newServer(path string) (*server, error) {
+	ds, err := trackml.NewDataset(path, 0, -1, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	srv := &server{
+		path: path,
+		ds:   ds,
+		mux:  http.NewServeMux(),
+	}
+	for _, name := range ds.Names() {
+		srv.names = append(srv.names, filepath.Base(name))
+	}
+
+	srv.mux.HandleFunc("/", srv.handleIndex)
+	srv.mux.HandleFunc("/event/", srv.handleEvent)
+	return srv, nil
+}
+
+// Close releases the resources of the dataset.
+func (srv *server) Close() error {
+	return srv.ds.Close()
+}
+
+func (srv *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	srv.mux.ServeHTTP(w, r)
+}
+
+// event returns the named event, reading it from the dataset if it is not
+// the last one served.
+func (srv *server) event(name string) (trackml.Event, error) {
+	i := sort.SearchStrings(srv.names, name)
+	if i >= len(srv.names) || srv.names[i] != name {
+		return trackml.Event{}, errors.Errorf("no such event %q", name)
+	}
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	if srv.name == name {
+		return srv.evt, nil
+	}
+
+	evt, err := trackml.ReadMcEvent(srv.ds.Path(), name)
+	if err != nil {
+		return evt, err
+	}
+	srv.name = name
+	srv.evt = evt
+	return evt, nil
+}
+
+func (srv *server) handleIndex(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+
+	srv.render(w, indexTmpl, struct {
+		Path   string
+		Names  []string
+		Preds  bool
+		Events int
+	}{srv.path, srv.names, srv.preds != nil, len(srv.preds)})
+}
+
+// handleEvent serves /event/<name> and /event/<name>/display.png.
+func (srv *server) handleEvent(w http.ResponseWriter, r *http.Request) {
+	var (
+		path  = strings.TrimPrefix(r.URL.Path, "/event/")
+		name  = path
+		image = false
+	)
+	if strings.HasSuffix(path, "/display.png") {
+		name = strings.TrimSuffix(path, "/display.png")
+		image = true
+	}
+
+	evt, err := srv.event(name)
+	if err != nil {
+		log.Printf("could not read event %q: %v", name, err)
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if image {
+		srv.handleDisplay(w, r, evt)
+		return
+	}
+
+	page := struct {
+		Name   string
+		Stats  eventStats
+		Preds  bool
+		Score  float64
+		Tracks []trackml.TrackMatch
+		Good   int
+	}{
+		Name:  name,
+		Stats: newEventStats(evt),
+		Preds: srv.preds != nil,
+	}
+	if page.Preds {
+		labels := srv.preds.Labels(evt)
+		page.Score = trackml.Score(evt, labels)
+		page.Tracks = trackml.MatchTracks(evt, labels)
+		sort.SliceStable(page.Tracks, func(i, j int) bool {
+			return page.Tracks[i].Weight > page.Tracks[j].Weight
+		})
+		for _, trk := range page.Tracks {
+			if trk.Good() {
+				page.Good++
+			}
+		}
+	}
+
+	srv.render(w, eventTmpl, page)
+}
+
+// handleDisplay renders the x-y and r-z views of the event.
+// With the "pred" query parameter, hits are coloured by predicted track and
+// faulty tracks are highlighted.
+func (srv *server) handleDisplay(w http.ResponseWriter, r *http.Request, evt trackml.Event) {
+	var labels []int
+	pred := r.URL.Query().Get("pred") != ""
+	if pred {
+		if srv.preds == nil {
+			http.Error(w, "no predictions loaded", http.StatusNotFound)
+			return
+		}
+		labels = srv.preds.Labels(evt)
+	}
+
+	d := display.New(evt, labels)
+	d.Detector = srv.det
+	d.Highlight = pred
+
+	buf := new(bytes.Buffer)
+	err := d.Render(buf, 40*vg.Centimeter, 20*vg.Centimeter, "png")
+	if err != nil {
+		log.Printf("could not render event %d: %+v", evt.ID, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Write(buf.Bytes())
+}
+
+func (srv *server) render(w http.ResponseWriter, tmpl *template.Template, data interface{}) {
+	buf := new(bytes.Buffer)
+	err := tmpl.Execute(buf, data)
+	if err != nil {
+		log.Printf("could not execute template %q: %+v", tmpl.Name(), err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(buf.Bytes())
+}
+
+// eventStats summarizes the hits and particles of an event.
+type eventStats struct {
+	Hits      int
+	Cells     int
+	Particles int
+	Noise     int // number of hits not associated with any particle
+	MeanHits  float64
+	Volumes   []volumeStats
+}
+
+type volumeStats struct {
+	ID   int
+	Hits int
+}
+
+func newEventStats(evt trackml.Event) eventStats {
+	stats := eventStats{
+		Hits:      len(evt.Hits),
+		Cells:     len(evt.Cells),
+		Particles: len(evt.Ps),
+	}
+
+	for _, mc := range evt.Mcs {
+		if mc.PID == 0 {
+			stats.Noise++
+		}
+	}
+	if stats.Particles > 0 {
+		stats.MeanHits = float64(len(evt.Mcs)-stats.Noise) / float64(stats.Particles)
+	}
+
+	vols := make(map[int]int)
+	for _, hit := range evt.Hits {
+		vols[hit.VolumeID]++
+	}
+	for id, n := range vols {
+		stats.Volumes = append(stats.Volumes, volumeStats{ID: id, Hits: n})
+	}
+	sort.Slice(stats.Volumes, func(i, j int) bool {
+		return stats.Volumes[i].ID < stats.Volumes[j].ID
+	})
+
+	return stats
+}
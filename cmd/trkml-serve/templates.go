@@ -0,0 +1,83 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"html/template"
+)
+
+const style = `
+<style>
+body  { font-family: sans-serif; margin: 1em 2em; }
+table { border-collapse: collapse; margin: 1em 0; }
+th, td { border: 1px solid #ccc; padding: 0.2em 0.6em; text-align: right; }
+th    { background: #eee; }
+tr.fake td { color: #c00; }
+img   { max-width: 100%; }
+</style>
+`
+
+var indexTmpl = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>trkml-serve: {{.Path}}</title>
+` + style + `
+</head>
+<body>
+<h1>{{.Path}}</h1>
+<p>{{len .Names}} events{{if .Preds}}, predictions for {{.Events}} events{{end}}.</p>
+<ul>
+{{- range .Names}}
+<li><a href="/event/{{.}}">{{.}}</a></li>
+{{- end}}
+</ul>
+</body>
+</html>
+`))
+
+var eventTmpl = template.Must(template.New("event").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>trkml-serve: {{.Name}}</title>
+` + style + `
+</head>
+<body>
+<p><a href="/">&larr; events</a></p>
+<h1>{{.Name}}</h1>
+
+<h2>Statistics</h2>
+<table>
+<tr><th>hits</th><td>{{.Stats.Hits}}</td></tr>
+<tr><th>cells</th><td>{{.Stats.Cells}}</td></tr>
+<tr><th>particles</th><td>{{.Stats.Particles}}</td></tr>
+<tr><th>noise hits</th><td>{{.Stats.Noise}}</td></tr>
+<tr><th>hits per particle</th><td>{{printf "%.2f" .Stats.MeanHits}}</td></tr>
+</table>
+<table>
+<tr><th>volume</th><th>hits</th></tr>
+{{- range .Stats.Volumes}}
+<tr><td>{{.ID}}</td><td>{{.Hits}}</td></tr>
+{{- end}}
+</table>
+
+<h2>Monte-Carlo particles</h2>
+<img src="/event/{{.Name}}/display.png">
+
+{{- if .Preds}}
+<h2>Predicted tracks</h2>
+<p>score: {{printf "%.6f" .Score}}, good tracks: {{.Good}}/{{len .Tracks}}.</p>
+<img src="/event/{{.Name}}/display.png?pred=1">
+<table>
+<tr><th>track</th><th>hits</th><th>particle</th><th>particle hits</th><th>shared hits</th><th>weight</th><th>good</th></tr>
+{{- range .Tracks}}
+<tr{{if not .Good}} class="fake"{{end}}><td>{{.TrackID}}</td><td>{{.Hits}}</td><td>{{.PID}}</td><td>{{.PHits}}</td><td>{{.MajHits}}</td><td>{{printf "%.6f" .Weight}}</td><td>{{.Good}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+</body>
+</html>
+`))
@@ -0,0 +1,127 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/sbinet/go-trackml"
+	"github.com/sbinet/go-trackml/eval"
+)
+
+const compareUsage = `trkml compare compares the predictions of two submission files, event by
+event, against the Monte-Carlo truth of a dataset.
+
+compare prints the bootstrap confidence intervals on the mean score of each
+submission and on their mean difference, with the p-values of a sign test
+and of a paired bootstrap test of the difference.
+
+Blacklisted hits are ignored when the blacklist files of the events are
+present in the dataset, or in the dataset given with -blacklist.
+
+Usage:
+
+  $> trkml compare [OPTIONS] <path-to-dataset> <path-to-submission-a> <path-to-submission-b>
+
+Examples:
+
+  $> trkml predict -o=theta.csv.gz ./train_sample.zip
+  $> trkml predict -merge -o=merge.csv.gz ./train_sample.zip
+  $> trkml compare ./train_sample.zip ./merge.csv.gz ./theta.csv.gz
+  $> trkml compare -n=5 -level=0.99 ./train_sample.zip ./a.csv ./b.csv
+  $> trkml compare -blacklist=./blacklist_training.zip ./train_1.zip ./a.csv ./b.csv
+`
+
+func runCompare(args []string) error {
+	fset := newFlagSet("compare", compareUsage)
+	nevts := fset.Int("n", -1, "number of events to process (-1 for all)")
+	nboot := fset.Int("boot", 10000, "number of bootstrap resamples")
+	level := fset.Float64("level", 0.95, "confidence level of the intervals")
+	seed := fset.Int64("seed", 1234, "seed of the bootstrap resampling")
+	blacklist := fset.String("blacklist", "", "path to the blacklist files of the dataset")
+	fset.Parse(args)
+
+	if fset.NArg() != 3 {
+		fset.Usage()
+		return errors.Errorf("missing path to event dataset or submission files")
+	}
+	if *level <= 0 || *level >= 1 {
+		return errors.Errorf("invalid confidence level %v", *level)
+	}
+
+	var (
+		path  = fset.Arg(0)
+		preds [2]trackml.Predictions
+	)
+	for i := range preds {
+		sub, err := trackml.ReadSubmission(fset.Arg(i + 1))
+		if err != nil {
+			return errors.Wrapf(err, "could not read submission %q", fset.Arg(i+1))
+		}
+		preds[i] = sub
+	}
+
+	var reader trackml.EventReader
+	if *blacklist != "" {
+		reader = trackml.WithBlacklist(*blacklist, nil)
+	}
+
+	ds, err := trackml.NewDataset(path, 0, *nevts, reader)
+	if err != nil {
+		return errors.Wrapf(err, "could not open dataset %q", path)
+	}
+	defer ds.Close()
+
+	var (
+		as, bs []float64
+		tw     = tabwriter.NewWriter(os.Stdout, 0, 8, 1, ' ', 0)
+	)
+	fmt.Fprintf(tw, "event\ta\tb\tdiff\n")
+	for ds.Next() {
+		evt := ds.Event()
+		var scores [2]float64
+		for i, sub := range preds {
+			if _, ok := sub[evt.ID]; !ok {
+				return errors.Errorf("no prediction for event %v in %q", evt.ID, fset.Arg(i+1))
+			}
+			scores[i] = trackml.Score(evt, sub.Labels(evt))
+		}
+		as = append(as, scores[0])
+		bs = append(bs, scores[1])
+		fmt.Fprintf(tw, "%d\t%v\t%v\t%+v\n", evt.ID, scores[0], scores[1], scores[0]-scores[1])
+		evt.Delete()
+	}
+	if err := ds.Err(); err != nil {
+		return err
+	}
+
+	boot := eval.Bootstrap{N: *nboot, Level: *level, Seed: *seed}
+	cmp, err := boot.Compare(as, bs)
+	if err != nil {
+		return err
+	}
+	ia := boot.Mean(as)
+	ib := boot.Mean(bs)
+
+	fmt.Fprintf(tw, "mean\t%v\t%v\t%+v\n", ia.Mean, ib.Mean, cmp.Diff.Mean)
+	fmt.Fprintf(tw, "%v%% ci\t%s\t%s\t%s\n", 100*boot.Level, ci(ia), ci(ib), ci(cmp.Diff))
+	err = tw.Flush()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("\nwins/losses/ties: %d/%d/%d\n", cmp.Wins, cmp.Losses, cmp.Ties)
+	fmt.Printf("sign test:        p=%.4g\n", cmp.SignP)
+	fmt.Printf("paired bootstrap: p=%.4g\n", cmp.BootP)
+	return nil
+}
+
+// ci formats the bounds of a confidence interval, for display.
+func ci(iv eval.Interval) string {
+	return fmt.Sprintf("[%.5f, %.5f]", iv.Lo, iv.Hi)
+}
@@ -0,0 +1,281 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"archive/zip"
+	"io"
+	"log"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sbinet/go-trackml"
+)
+
+const convertUsage = `trkml convert copies the events of a dataset into a directory or, if
+the output name has a ".zip" extension, a zip file.
+
+The dataset may be a directory, a zip file or a (compressed) tar file.
+The files of the events are copied as is, compressed or not.
+
+Usage:
+
+  $> trkml convert [OPTIONS] <path-to-dataset> <output>
+
+Examples:
+
+  $> trkml convert ./train_sample.zip ./train_sample
+  $> trkml convert -n=10 ./train_sample ./small.zip
+  $> trkml convert ./train_sample.tar.gz ./train_sample.zip
+`
+
+func runConvert(args []string) error {
+	fset := newFlagSet("convert", convertUsage)
+	nevts := fset.Int("n", -1, "number of events to copy (-1 for all)")
+	fset.Parse(args)
+
+	if fset.NArg() != 2 {
+		fset.Usage()
+		return errors.Errorf("missing input dataset or output")
+	}
+
+	src, err := openSource(fset.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	return src.copyEvents(fset.Arg(1), src.events(*nevts))
+}
+
+const splitUsage = `trkml split randomly splits the events of a dataset into two datasets.
+
+Outputs with a ".zip" extension are zip files, other outputs are directories.
+
+Usage:
+
+  $> trkml split [OPTIONS] <path-to-dataset> <output-1> <output-2>
+
+Examples:
+
+  $> trkml split ./train_sample.zip ./train ./valid
+  $> trkml split -frac=0.9 -seed=42 ./train_sample.zip ./train.zip ./valid.zip
+`
+
+func runSplit(args []string) error {
+	fset := newFlagSet("split", splitUsage)
+	frac := fset.Float64("frac", 0.8, "fraction of the events to copy into the first output")
+	seed := fset.Int64("seed", 1234, "seed for the random selection of events")
+	fset.Parse(args)
+
+	if fset.NArg() != 3 {
+		fset.Usage()
+		return errors.Errorf("missing input dataset or outputs")
+	}
+	if *frac < 0 || *frac > 1 {
+		return errors.Errorf("invalid fraction %v", *frac)
+	}
+
+	src, err := openSource(fset.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	names := src.events(-1)
+
+	rnd := rand.New(rand.NewSource(*seed))
+	rnd.Shuffle(len(names), func(i, j int) {
+		names[i], names[j] = names[j], names[i]
+	})
+
+	n := int(*frac*float64(len(names)) + 0.5)
+	for i, sel := range [][]string{names[:n], names[n:]} {
+		sort.Strings(sel)
+		err = src.copyEvents(fset.Arg(i+1), sel)
+		if err != nil {
+			return err
+		}
+		log.Printf("copied %d events to %q", len(sel), fset.Arg(i+1))
+	}
+	return nil
+}
+
+// source is a dataset of events, stored in a directory, a zip file or a tar
+// file.
+// Tar files are read from the directory where the dataset extracted them.
+type source struct {
+	ds trackml.Dataset
+	f  *os.File
+	zr *zip.Reader // nil for directories and tar files
+}
+
+func openSource(path string) (*source, error) {
+	ds, err := trackml.NewDataset(path, 0, -1, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not open dataset %q", path)
+	}
+	src := &source{ds: ds}
+
+	fi, err := os.Stat(ds.Path())
+	if err != nil {
+		src.Close()
+		return nil, errors.Wrapf(err, "could not stat dataset")
+	}
+	if fi.IsDir() {
+		return src, nil
+	}
+
+	src.f, err = os.Open(ds.Path())
+	if err != nil {
+		src.Close()
+		return nil, errors.Wrapf(err, "could not open dataset")
+	}
+	src.zr, err = zip.NewReader(src.f, fi.Size())
+	if err != nil {
+		src.Close()
+		return nil, errors.Wrapf(err, "could not open zip-dataset")
+	}
+	return src, nil
+}
+
+func (src *source) Close() error {
+	if src.f != nil {
+		src.f.Close()
+	}
+	return src.ds.Close()
+}
+
+// events returns the names of the first n events of the dataset, or of all
+// the events if n is negative.
+func (src *source) events(n int) []string {
+	names := src.ds.Names()
+	if n >= 0 && n < len(names) {
+		names = names[:n]
+	}
+	return append([]string(nil), names...)
+}
+
+// copyEvents copies the files of the named events into the output directory
+// or zip file.
+func (src *source) copyEvents(oname string, names []string) error {
+	dst, err := newSink(oname)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	for _, name := range names {
+		err = src.copyEvent(dst, name)
+		if err != nil {
+			return errors.Wrapf(err, "could not copy event %q", filepath.Base(name))
+		}
+	}
+
+	return dst.Close()
+}
+
+func (src *source) copyEvent(dst *sink, name string) error {
+	if src.zr == nil {
+		fnames, err := filepath.Glob(name + "-*")
+		if err != nil {
+			return err
+		}
+		for _, fname := range fnames {
+			f, err := os.Open(fname)
+			if err != nil {
+				return err
+			}
+			err = dst.copy(filepath.Base(fname), f)
+			f.Close()
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, zf := range src.zr.File {
+		if !strings.HasPrefix(zf.Name, name+"-") {
+			continue
+		}
+		r, err := zf.Open()
+		if err != nil {
+			return err
+		}
+		err = dst.copy(filepath.Base(zf.Name), r)
+		r.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sink is the output of copied events, a directory or a zip file.
+type sink struct {
+	dir string
+	f   *os.File
+	zw  *zip.Writer // nil for directories
+}
+
+func newSink(name string) (*sink, error) {
+	if !strings.HasSuffix(name, ".zip") {
+		err := os.MkdirAll(name, 0755)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not create output directory")
+		}
+		return &sink{dir: name}, nil
+	}
+
+	f, err := os.Create(name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not create output zip file")
+	}
+	return &sink{f: f, zw: zip.NewWriter(f)}, nil
+}
+
+func (dst *sink) copy(name string, r io.Reader) error {
+	if dst.zw != nil {
+		w, err := dst.zw.Create(name)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(w, r)
+		return err
+	}
+
+	f, err := os.Create(filepath.Join(dst.dir, name))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(f, r)
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func (dst *sink) Close() error {
+	if dst.zw == nil || dst.f == nil {
+		return nil
+	}
+	err := dst.zw.Close()
+	if err != nil {
+		return errors.Wrapf(err, "could not close output zip file")
+	}
+	err = dst.f.Close()
+	dst.f = nil
+	if err != nil {
+		return errors.Wrapf(err, "could not close output zip file")
+	}
+	return nil
+}
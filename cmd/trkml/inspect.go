@@ -0,0 +1,154 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/sbinet/go-trackml"
+	"github.com/sbinet/go-trackml/stats"
+)
+
+const inspectUsage = `trkml inspect prints statistics about the events of a dataset.
+
+Usage:
+
+  $> trkml inspect [OPTIONS] <path-to-dataset>
+
+Examples:
+
+  $> trkml inspect ./train_sample.zip
+  $> trkml inspect -n=5 -layers ./train_sample.zip
+  $> trkml inspect -json ./train_sample.zip > stats.json
+`
+
+func runInspect(args []string) error {
+	fset := newFlagSet("inspect", inspectUsage)
+	asJSON := fset.Bool("json", false, "print statistics in JSON")
+	doLayers := fset.Bool("layers", false, "print the number of hits per detector layer")
+	nevts := fset.Int("n", -1, "number of events to process (-1 for all)")
+	schema := fset.Bool("schema", true, "check the CSV headers of the event files")
+	fset.Parse(args)
+
+	path := fset.Arg(0)
+	if path == "" {
+		fset.Usage()
+		return errors.Errorf("missing path to event dataset")
+	}
+
+	ds, err := trackml.NewDataset(path, 0, *nevts, nil)
+	if err != nil {
+		return errors.Wrapf(err, "could not open dataset %q", path)
+	}
+	defer ds.Close()
+
+	var (
+		names = ds.Names()
+		evts  []stats.Event
+		tot   stats.Dataset
+	)
+	for i := 0; ds.Next(); i++ {
+		evt := ds.Event()
+		st := stats.NewEvent(evt)
+		if *schema {
+			err := trackml.CheckSchema(ds.Path(), filepath.Base(names[i]))
+			if err != nil {
+				st.Schema = err.Error()
+			}
+		}
+		evts = append(evts, st)
+		tot.Add(st)
+		evt.Delete()
+	}
+	if err := ds.Err(); err != nil {
+		return err
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(struct {
+			Events  []stats.Event `json:"events"`
+			Dataset stats.Dataset `json:"dataset"`
+		}{evts, tot})
+	}
+
+	return printStats(os.Stdout, evts, tot, *doLayers)
+}
+
+// printStats prints the statistics of the events and of the dataset as
+// tables.
+func printStats(w io.Writer, evts []stats.Event, tot stats.Dataset, doLayers bool) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 1, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "event\thits\tcells\tparticles\tnoise-frac\tweight\thits/particle\tcells/hit\t\n")
+	for _, st := range evts {
+		fmt.Fprintf(tw, "%d\t%d\t%d\t%d\t%.4f\t%.4f\t%.2f\t%.2f\t\n",
+			st.ID, st.Hits, st.Cells, st.Particles,
+			st.NoiseFraction, st.Weight,
+			st.HitsPerParticle.Mean, st.CellsPerHit.Mean,
+		)
+	}
+	err := tw.Flush()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "\n")
+	tw = tabwriter.NewWriter(w, 0, 8, 1, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "\tmean\tmin\tmax\t\n")
+	for _, v := range []struct {
+		name string
+		s    stats.Summary
+	}{
+		{"hits/event", tot.Hits},
+		{"cells/event", tot.Cells},
+		{"particles/event", tot.Particles},
+		{"weight/event", tot.Weight},
+		{"hits/particle", tot.HitsPerParticle},
+		{"cells/hit", tot.CellsPerHit},
+	} {
+		fmt.Fprintf(tw, "%s\t%.4g\t%.4g\t%.4g\t\n", v.name, v.s.Mean, v.s.Min, v.s.Max)
+	}
+	err = tw.Flush()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "\nevents: %d, hits: %d, noise fraction: %.4f\n",
+		tot.Events, tot.TotalHits, tot.NoiseFraction,
+	)
+
+	if doLayers {
+		fmt.Fprintf(w, "\n")
+		tw = tabwriter.NewWriter(w, 0, 8, 1, ' ', tabwriter.AlignRight)
+		fmt.Fprintf(tw, "volume\tlayer\thits\thits/event\t\n")
+		for _, l := range tot.Layers {
+			fmt.Fprintf(tw, "%d\t%d\t%d\t%.1f\t\n",
+				l.Volume, l.Layer, l.Hits, float64(l.Hits)/float64(tot.Events),
+			)
+		}
+		err = tw.Flush()
+		if err != nil {
+			return err
+		}
+	}
+
+	if tot.SchemaErrors > 0 {
+		fmt.Fprintf(w, "\n")
+		for _, st := range evts {
+			if st.Schema == "" {
+				continue
+			}
+			fmt.Fprintf(w, "event %d: %s\n", st.ID, st.Schema)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// trkml is a tool to handle TrackML datasets, predictions and submissions.
+//
+// Usage:
+//
+//	$> trkml <command> [OPTIONS] <arguments>
+//
+// Commands:
+//
+//	compare   compare the predictions of two submission files
+//	convert   copy the events of a dataset into a directory or zip file
+//	inspect   print statistics about the events of a dataset
+//	predict   predict and score the tracks of the events of a dataset
+//	score     score the predictions of a submission file
+//	split     split a dataset into two datasets
+//	submit    create a submission file from a test dataset
+//	validate  check the events of a dataset can be read
+//
+// Examples:
+//
+//	$> trkml inspect ./train_sample.zip
+//	$> trkml predict -ncpus=-1 -n=5 ./train_sample.zip
+//	$> trkml submit -o=submission.csv.gz ./test.zip
+//	$> trkml score ./train_sample.zip ./submission.csv.gz
+//	$> trkml compare ./train_sample.zip ./a.csv.gz ./b.csv.gz
+//	$> trkml split -frac=0.8 ./train_sample.zip train valid
+//
+// Use "trkml <command> -h" for more informations about a command.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+)
+
+// command is a trkml sub-command.
+type command struct {
+	name  string
+	short string
+	run   func(args []string) error
+}
+
+var commands = []command{
+	{"compare", "compare the predictions of two submission files", runCompare},
+	{"convert", "copy the events of a dataset into a directory or zip file", runConvert},
+	{"inspect", "print statistics about the events of a dataset", runInspect},
+	{"predict", "predict and score the tracks of the events of a dataset", runPredict},
+	{"score", "score the predictions of a submission file", runScore},
+	{"split", "split a dataset into two datasets", runSplit},
+	{"submit", "create a submission file from a test dataset", runSubmit},
+	{"validate", "check the events of a dataset can be read", runValidate},
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("trkml: ")
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, `trkml is a tool to handle TrackML datasets, predictions and submissions.
+
+Usage:
+
+  $> trkml <command> [OPTIONS] <arguments>
+
+Commands:
+
+`)
+		for _, cmd := range commands {
+			fmt.Fprintf(os.Stderr, "  %-9s %s\n", cmd.name, cmd.short)
+		}
+		fmt.Fprintf(os.Stderr, `
+Examples:
+
+  $> trkml inspect ./train_sample.zip
+  $> trkml predict -ncpus=-1 -n=5 ./train_sample.zip
+  $> trkml submit -o=submission.csv.gz ./test.zip
+  $> trkml score ./train_sample.zip ./submission.csv.gz
+  $> trkml compare ./train_sample.zip ./a.csv.gz ./b.csv.gz
+  $> trkml split -frac=0.8 ./train_sample.zip train valid
+
+Use "trkml <command> -h" for more informations about a command.
+`)
+	}
+
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		log.Fatalf("missing command")
+	}
+
+	name := flag.Arg(0)
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+		log.SetPrefix("trkml " + name + ": ")
+		err := cmd.run(flag.Args()[1:])
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	flag.Usage()
+	log.Fatalf("unknown command %q", name)
+}
+
+// newFlagSet returns the flag set of a sub-command, with the provided usage
+// message.
+func newFlagSet(name, usage string) *flag.FlagSet {
+	fset := flag.NewFlagSet(name, flag.ExitOnError)
+	fset.Usage = func() {
+		fmt.Fprintf(os.Stderr, "%s\nOptions:\n\n", usage)
+		fset.PrintDefaults()
+	}
+	return fset
+}
@@ -0,0 +1,218 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sbinet/go-trackml"
+	"github.com/sbinet/go-trackml/clustering"
+	"github.com/sbinet/go-trackml/eval"
+)
+
+// modelFlags configures the Hough transform classifier and the evaluation
+// of events.
+type modelFlags struct {
+	ncpus   *int
+	events  *int
+	merge   *bool
+	minHits *int
+}
+
+func newModelFlags(fset *flag.FlagSet) modelFlags {
+	return modelFlags{
+		ncpus:   fset.Int("ncpus", 1, "number of goroutines to use for the prediction"),
+		events:  fset.Int("events", 1, "number of events to process concurrently, sharing the -ncpus goroutines"),
+		merge:   fset.Bool("merge", false, "resolve overlapping tracks by quality instead of theta order"),
+		minHits: fset.Int("min-hits", 9, "minimum number of hits of a track"),
+	}
+}
+
+// runner returns the evaluation runner of the configured classifier.
+func (mf modelFlags) runner() *eval.Runner {
+	ncpus := *mf.ncpus
+	if ncpus <= 0 {
+		ncpus = runtime.NumCPU() + 1
+	}
+	r := eval.New(mf.model, ncpus)
+	r.Events = *mf.events
+	return r
+}
+
+// model returns the configured classifier, using n goroutines.
+func (mf modelFlags) model(n int) clustering.Classifier {
+	const (
+		nbinsR0Inv = 200
+		nbinsGamma = 500
+		nbinsTheta = 500
+	)
+
+	if *mf.merge {
+		return clustering.NewPipeline(
+			clustering.NewFinder(n, nbinsR0Inv, nbinsGamma, nbinsTheta, *mf.minHits),
+			clustering.NewMerger(*mf.minHits),
+		)
+	}
+	return clustering.New(n, nbinsR0Inv, nbinsGamma, nbinsTheta, *mf.minHits)
+}
+
+const predictUsage = `trkml predict predicts and scores the tracks of the events of a dataset.
+
+Events are processed -events at a time, each with -ncpus/-events goroutines.
+
+Blacklisted hits are ignored by the score. With -rm-blacklisted, they are
+removed from the events before the prediction.
+
+Usage:
+
+  $> trkml predict [OPTIONS] <path-to-dataset>
+
+Examples:
+
+  $> trkml predict ./train_sample.zip
+  $> trkml predict -ncpus=-1 -n=5 -merge ./train_sample.zip
+  $> trkml predict -ncpus=8 -events=4 ./train_sample.zip
+  $> trkml predict -o=pred.csv.gz ./train_sample.zip
+  $> trkml predict -blacklist=./blacklist_training.zip -rm-blacklisted ./train_1.zip
+`
+
+func runPredict(args []string) error {
+	fset := newFlagSet("predict", predictUsage)
+	mflags := newModelFlags(fset)
+	nevts := fset.Int("n", -1, "number of events to process (-1 for all)")
+	oname := fset.String("o", "", "path to a submission file to write the predictions to")
+	blacklist := fset.String("blacklist", "", "path to the blacklist files of the dataset")
+	rmbl := fset.Bool("rm-blacklisted", false, "remove blacklisted hits and particles before predicting")
+	fset.Parse(args)
+
+	path := fset.Arg(0)
+	if path == "" {
+		fset.Usage()
+		return errors.Errorf("missing path to event dataset")
+	}
+
+	run := mflags.runner()
+	if *blacklist != "" {
+		run.Reader = trackml.WithBlacklist(*blacklist, nil)
+	}
+	if *rmbl {
+		run.Reader = trackml.WithoutBlacklisted(run.Reader)
+	}
+
+	var sub *trackml.Submission
+	if *oname != "" {
+		var err error
+		sub, err = trackml.CreateSubmission(*oname)
+		if err != nil {
+			return errors.Wrapf(err, "could not create submission file")
+		}
+		defer sub.Close()
+
+		run.Emit = func(evt trackml.Event, labels []int) error {
+			err := sub.Append(evt, labels)
+			if err != nil {
+				return errors.Wrapf(err, "could not append event %v to submission", evt.ID)
+			}
+			return nil
+		}
+	}
+
+	res, sum, err := run.Run(path, 0, *nevts)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 1, ' ', 0)
+	fmt.Fprintf(tw, "event\thits\tscore\tread\tpredict\n")
+	for _, r := range res {
+		fmt.Fprintf(tw, "%d\t%d\t%v\t%v\t%v\n", r.Event, r.Hits, r.Score, round(r.Read), round(r.Predict))
+	}
+	if sum.Scored > 0 {
+		iv := eval.NewBootstrap().Mean(eval.Scores(res))
+		fmt.Fprintf(tw, "mean\t\t%v\t\n", sum.Mean)
+		fmt.Fprintf(tw, "%v%% ci\t\t%s\t\n", 100*iv.Level, ci(iv))
+		fmt.Fprintf(tw, "median\t\t%v\t\n", sum.Median)
+		fmt.Fprintf(tw, "std-dev\t\t%v\t\n", sum.StdDev)
+	}
+	fmt.Fprintf(tw, "total\t\t\t%v\t%v\n", round(sum.Read), round(sum.Predict))
+	err = tw.Flush()
+	if err != nil {
+		return err
+	}
+	log.Printf("processed %d events (%d scored) in %v", sum.Events, sum.Scored, round(sum.Elapsed))
+
+	if sub != nil {
+		err = sub.Close()
+		if err != nil {
+			return errors.Wrapf(err, "could not close submission")
+		}
+	}
+	return nil
+}
+
+// round rounds durations to the millisecond, for display.
+func round(d time.Duration) time.Duration {
+	return d.Round(time.Millisecond)
+}
+
+const submitUsage = `trkml submit creates a submission file from the events of a test dataset.
+
+Usage:
+
+  $> trkml submit [OPTIONS] <path-to-test-dataset>
+
+Examples:
+
+  $> trkml submit ./test.zip
+  $> trkml submit -ncpus=-1 -merge -o=hough.csv.gz ./test.zip
+`
+
+func runSubmit(args []string) error {
+	fset := newFlagSet("submit", submitUsage)
+	mflags := newModelFlags(fset)
+	oname := fset.String("o", "submission.csv.gz", "path to the submission file")
+	fset.Parse(args)
+
+	path := fset.Arg(0)
+	if path == "" {
+		fset.Usage()
+		return errors.Errorf("missing path to test dataset")
+	}
+
+	sub, err := trackml.CreateSubmission(*oname)
+	if err != nil {
+		return errors.Wrapf(err, "could not create submission file")
+	}
+	defer sub.Close()
+
+	run := mflags.runner()
+	run.Reader = trackml.ReadEvent
+	run.Emit = func(evt trackml.Event, labels []int) error {
+		log.Printf("processed event %v", evt.ID)
+		err := sub.Append(evt, labels)
+		if err != nil {
+			return errors.Wrapf(err, "could not append event %v to submission", evt.ID)
+		}
+		return nil
+	}
+
+	_, _, err = run.Run(path, 0, -1)
+	if err != nil {
+		return err
+	}
+
+	err = sub.Close()
+	if err != nil {
+		return errors.Wrapf(err, "could not close submission")
+	}
+	return nil
+}
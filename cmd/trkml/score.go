@@ -0,0 +1,89 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/sbinet/go-trackml"
+	"github.com/sbinet/go-trackml/eval"
+)
+
+const scoreUsage = `trkml score scores the predictions of a submission file against the
+Monte-Carlo truth of a dataset.
+
+Blacklisted hits are ignored when the blacklist files of the events are
+present in the dataset, or in the dataset given with -blacklist.
+
+Usage:
+
+  $> trkml score [OPTIONS] <path-to-dataset> <path-to-submission>
+
+Examples:
+
+  $> trkml score ./train_sample.zip ./submission.csv.gz
+  $> trkml score -n=5 ./train_sample.zip ./submission.csv
+  $> trkml score -blacklist=./blacklist_training.zip ./train_1.zip ./submission.csv.gz
+`
+
+func runScore(args []string) error {
+	fset := newFlagSet("score", scoreUsage)
+	nevts := fset.Int("n", -1, "number of events to process (-1 for all)")
+	blacklist := fset.String("blacklist", "", "path to the blacklist files of the dataset")
+	fset.Parse(args)
+
+	path := fset.Arg(0)
+	if path == "" {
+		fset.Usage()
+		return errors.Errorf("missing path to event dataset")
+	}
+	sname := fset.Arg(1)
+	if sname == "" {
+		fset.Usage()
+		return errors.Errorf("missing path to submission file")
+	}
+
+	preds, err := trackml.ReadSubmission(sname)
+	if err != nil {
+		return errors.Wrapf(err, "could not read submission")
+	}
+
+	var reader trackml.EventReader
+	if *blacklist != "" {
+		reader = trackml.WithBlacklist(*blacklist, nil)
+	}
+
+	ds, err := trackml.NewDataset(path, 0, *nevts, reader)
+	if err != nil {
+		return errors.Wrapf(err, "could not open dataset %q", path)
+	}
+	defer ds.Close()
+
+	var (
+		scores []float64
+		tw     = tabwriter.NewWriter(os.Stdout, 0, 8, 1, ' ', 0)
+	)
+	fmt.Fprintf(tw, "event\tscore\n")
+	for ds.Next() {
+		evt := ds.Event()
+		if _, ok := preds[evt.ID]; !ok {
+			return errors.Errorf("no prediction for event %v", evt.ID)
+		}
+		score := trackml.Score(evt, preds.Labels(evt))
+		scores = append(scores, score)
+		fmt.Fprintf(tw, "%d\t%v\n", evt.ID, score)
+		evt.Delete()
+	}
+	if err := ds.Err(); err != nil {
+		return err
+	}
+	iv := eval.NewBootstrap().Mean(scores)
+	fmt.Fprintf(tw, "mean\t%v\n", iv.Mean)
+	fmt.Fprintf(tw, "%v%% ci\t%s\n", 100*iv.Level, ci(iv))
+	return tw.Flush()
+}
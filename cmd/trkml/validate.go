@@ -0,0 +1,72 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/sbinet/go-trackml/validate"
+)
+
+const validateUsage = `trkml validate checks the integrity of the events of a dataset.
+
+trkml validate checks that the files of each event are present, with the
+expected CSV columns, that hit IDs are unique and contiguous, that cells and
+truth refer to existing hits, that truth weights sum to 1 and that the number
+of hits of each particle agrees with truth.
+
+Usage:
+
+  $> trkml validate [OPTIONS] <path-to-dataset>
+
+Examples:
+
+  $> trkml validate ./train_sample.zip
+  $> trkml validate -n=5 -json ./train_sample.zip
+`
+
+func runValidate(args []string) error {
+	fset := newFlagSet("validate", validateUsage)
+	asJSON := fset.Bool("json", false, "print the report in JSON")
+	nevts := fset.Int("n", -1, "number of events to process (-1 for all)")
+	tol := fset.Float64("weight-tol", 1e-4, "tolerance on the sum of truth weights")
+	fset.Parse(args)
+
+	path := fset.Arg(0)
+	if path == "" {
+		fset.Usage()
+		return errors.Errorf("missing path to event dataset")
+	}
+
+	v := validate.New()
+	v.WeightTol = *tol
+	rep, err := v.Dataset(path, 0, *nevts)
+	if err != nil {
+		return errors.Wrapf(err, "could not open dataset %q", path)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(rep)
+		if err != nil {
+			return err
+		}
+	} else {
+		for _, e := range rep.Errors {
+			fmt.Println(e.Error())
+		}
+	}
+
+	if err := rep.Err(); err != nil {
+		return err
+	}
+	log.Printf("%d events are valid", rep.Events)
+	return nil
+}
@@ -0,0 +1,161 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package trackml
+
+import (
+	"bufio"
+	"encoding/csv"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ReadCSV reads the rows of the named CSV file into the slice pointed to by
+// ptr, a pointer to a slice of structs.
+// The file may be gzip- or zstd-compressed.
+//
+// The first line of the file holds the names of the columns.
+// Each struct field with a csv tag is decoded from the column of that name,
+// whatever its position.
+// Columns without a matching field are ignored.
+// Fields may be signed integers, floating-point numbers or strings.
+func ReadCSV(fname string, ptr interface{}) error {
+	f, err := openFile(osFS{}, fname)
+	if err != nil {
+		return errors.Wrapf(err, "could not open CSV file")
+	}
+	defer f.Close()
+
+	return decodeCSV(bufio.NewReader(f), fname, ptr)
+}
+
+// decodeCSV decodes the CSV content of r into the slice pointed to by ptr.
+// name is used in error messages.
+func decodeCSV(r io.Reader, name string, ptr interface{}) error {
+	rv := reflect.ValueOf(ptr)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Slice ||
+		rv.Elem().Type().Elem().Kind() != reflect.Struct {
+		return errors.Errorf("invalid type %T, want a pointer to a slice of structs", ptr)
+	}
+	var (
+		slice = rv.Elem()
+		typ   = slice.Type().Elem()
+	)
+
+	cr := csv.NewReader(r)
+	cr.ReuseRecord = true
+
+	hdr, err := cr.Read()
+	if err != nil {
+		if err == io.EOF {
+			return errors.Errorf("%s: missing header line", name)
+		}
+		return errors.Wrapf(err, "%s: could not read header line", name)
+	}
+	cols := make(map[string]int, len(hdr))
+	for i, col := range hdr {
+		cols[strings.TrimSpace(col)] = i
+	}
+	cr.FieldsPerRecord = len(hdr)
+
+	fields, err := csvFields(typ, cols)
+	if err != nil {
+		return errors.Wrapf(err, "%s", name)
+	}
+
+	for line := 2; ; line++ {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return errors.Wrapf(err, "%s: could not read line %d", name, line)
+		}
+
+		v := reflect.New(typ).Elem()
+		for _, f := range fields {
+			err := f.decode(v.Field(f.field), rec[f.col])
+			if err != nil {
+				return errors.Errorf(
+					"%s:%d: invalid value %q for column %q: %v",
+					name, line, rec[f.col], f.name, err,
+				)
+			}
+		}
+		slice.Set(reflect.Append(slice, v))
+	}
+
+	return nil
+}
+
+// csvField maps a struct field to a CSV column.
+type csvField struct {
+	name  string // name of the column
+	field int    // index of the struct field
+	col   int    // index of the column
+}
+
+// csvFields returns the mapping of the fields of the struct type to the
+// provided columns.
+func csvFields(typ reflect.Type, cols map[string]int) ([]csvField, error) {
+	var fields []csvField
+	for i := 0; i < typ.NumField(); i++ {
+		ft := typ.Field(i)
+		name := ft.Tag.Get("csv")
+		if name == "" {
+			continue
+		}
+		switch ft.Type.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Float32, reflect.Float64, reflect.String:
+		default:
+			return nil, errors.Errorf("unsupported type %v for column %q", ft.Type, name)
+		}
+		col, ok := cols[name]
+		if !ok {
+			return nil, errors.Errorf("missing column %q", name)
+		}
+		fields = append(fields, csvField{name: name, field: i, col: col})
+	}
+	return fields, nil
+}
+
+func (f csvField) decode(v reflect.Value, s string) error {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Float32, reflect.Float64:
+		x, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(x)
+	case reflect.String:
+		v.SetString(s)
+	}
+	return nil
+}
+
+// csvColumns returns the names of the CSV columns of the provided struct
+// value, in the order of its fields.
+func csvColumns(v interface{}) []string {
+	var (
+		typ  = reflect.TypeOf(v)
+		cols []string
+	)
+	for i := 0; i < typ.NumField(); i++ {
+		if name := typ.Field(i).Tag.Get("csv"); name != "" {
+			cols = append(cols, name)
+		}
+	}
+	return cols
+}
@@ -0,0 +1,124 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package trackml
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadCSV(t *testing.T) {
+	testCSVDecoder(t, func(fname string) ([]Cell, error) {
+		var cells []Cell
+		err := ReadCSV(fname, &cells)
+		return cells, err
+	})
+}
+
+// testCSVDecoder tests the decoding of cells files by read.
+func testCSVDecoder(t *testing.T, read func(fname string) ([]Cell, error)) {
+	dir, err := ioutil.TempDir("", "trkml-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, tc := range []struct {
+		name string
+		data string
+		want []Cell
+		err  string
+	}{
+		{
+			name: "ordered",
+			data: "hit_id,ch0,ch1,value\n1,2,3,0.5\n4,5,6,0.25\n",
+			want: []Cell{{1, 2, 3, 0.5}, {4, 5, 6, 0.25}},
+		},
+		{
+			name: "reordered",
+			data: "value,ch1,hit_id,ch0\n0.5,3,1,2\n0.25,6,4,5\n",
+			want: []Cell{{1, 2, 3, 0.5}, {4, 5, 6, 0.25}},
+		},
+		{
+			name: "extra-columns",
+			data: "hit_id,extra,ch0,ch1,value,more\n1,x,2,3,0.5,y\n",
+			want: []Cell{{1, 2, 3, 0.5}},
+		},
+		{
+			name: "crlf-no-eol",
+			data: "hit_id,ch0,ch1,value\r\n1,2,3,0.5\r\n4,5,6,0.25",
+			want: []Cell{{1, 2, 3, 0.5}, {4, 5, 6, 0.25}},
+		},
+		{
+			name: "header-only",
+			data: "hit_id,ch0,ch1,value\n",
+		},
+		{
+			name: "missing-column",
+			data: "hit_id,ch0,value\n1,2,0.5\n",
+			err:  `missing-column.csv: missing column "ch1"`,
+		},
+		{
+			name: "invalid-value",
+			data: "hit_id,ch0,ch1,value\n1,2,3,0.5\n4,5,x,0.25\n",
+			err:  `invalid-value.csv:3: invalid value "x" for column "ch1"`,
+		},
+		{
+			name: "invalid-fields",
+			data: "hit_id,ch0,ch1,value\n1,2,3,0.5\n4,5,6\n",
+			err:  `wrong number of fields`,
+		},
+		{
+			name: "empty",
+			data: "",
+			err:  `empty.csv: missing header line`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fname := filepath.Join(dir, tc.name+".csv")
+			err := ioutil.WriteFile(fname, []byte(tc.data), 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := read(fname)
+			switch {
+			case err == nil && tc.err != "":
+				t.Fatalf("expected an error (%s)", tc.err)
+			case err != nil && tc.err == "":
+				t.Fatalf("unexpected error: %v", err)
+			case err != nil:
+				if !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("invalid error:\ngot = %v\nwant= %v", err, tc.err)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("invalid cells:\ngot = %v\nwant= %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDecodeCSVInvalidType(t *testing.T) {
+	const data = "hit_id\n1\n"
+	for _, ptr := range []interface{}{
+		[]Cell{},
+		new([]int),
+		new(Cell),
+		new([]struct {
+			ID []int `csv:"hit_id"`
+		}),
+	} {
+		err := decodeCSV(strings.NewReader(data), "data.csv", ptr)
+		if err == nil {
+			t.Fatalf("%T: expected an error", ptr)
+		}
+	}
+}
@@ -6,48 +6,58 @@
 package trackml
 
 import (
-	"archive/zip"
 	"io"
+	"io/fs"
 	"io/ioutil"
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/pkg/errors"
-	"go-hep.org/x/hep/csvutil"
 )
 
 type Cell struct {
-	HitID int
-	Ch0   int
-	Ch1   int
-	Value float64
+	HitID int     `csv:"hit_id"`
+	Ch0   int     `csv:"ch0"`
+	Ch1   int     `csv:"ch1"`
+	Value float64 `csv:"value"`
 }
 
 type Hit struct {
-	HitID    int
-	X, Y, Z  float64
-	VolumeID int
-	LayerID  int
-	ModuleID int
+	HitID    int     `csv:"hit_id"`
+	X        float64 `csv:"x"`
+	Y        float64 `csv:"y"`
+	Z        float64 `csv:"z"`
+	VolumeID int     `csv:"volume_id"`
+	LayerID  int     `csv:"layer_id"`
+	ModuleID int     `csv:"module_id"`
 }
 
 type Particle struct {
-	ID         int
-	Vx, Vy, Vz float64
-	Px, Py, Pz float64
-	Q          int
-	NHits      int
+	ID    int     `csv:"particle_id"`
+	Vx    float64 `csv:"vx"`
+	Vy    float64 `csv:"vy"`
+	Vz    float64 `csv:"vz"`
+	Px    float64 `csv:"px"`
+	Py    float64 `csv:"py"`
+	Pz    float64 `csv:"pz"`
+	Q     int     `csv:"q"`
+	NHits int     `csv:"nhits"`
 }
 
 type Truth struct {
-	HitID      int
-	PID        int
-	Tx, Ty, Tz float64
-	Px, Py, Pz float64
-	Weight     float64
+	HitID  int     `csv:"hit_id"`
+	PID    int     `csv:"particle_id"`
+	Tx     float64 `csv:"tx"`
+	Ty     float64 `csv:"ty"`
+	Tz     float64 `csv:"tz"`
+	Px     float64 `csv:"tpx"`
+	Py     float64 `csv:"tpy"`
+	Pz     float64 `csv:"tpz"`
+	Weight float64 `csv:"weight"`
 }
 
 // Event stores informations about a complete HEP event.
@@ -57,6 +67,8 @@ type Event struct {
 	Cells []Cell     // collection of cells for this event
 	Ps    []Particle // collection of reconstructed particles for this event
 	Mcs   []Truth    // Monte-Carlo truth for this event
+
+	Blacklist Blacklist // blacklisted hits and particles of this event, if any
 }
 
 // Delete zeroes all internal data of an Event and
@@ -66,61 +78,86 @@ func (evt *Event) Delete() {
 	evt.Cells = nil
 	evt.Ps = nil
 	evt.Mcs = nil
+	evt.Blacklist = Blacklist{}
 }
 
 // ReadMcEvent reads a complete Event value from the given path+prefix,
 // including Monte-Carlo informations.
+// The blacklist of the event is read too, if its files are present.
 func ReadMcEvent(path, evtid string) (Event, error) {
+	return ReadMcEventFS(osFS{}, path, evtid)
+}
+
+// ReadMcEventFS is like ReadMcEvent but reads the event from the named
+// directory or archive of fsys.
+func ReadMcEventFS(fsys fs.FS, name, evtid string) (Event, error) {
 	var (
 		evt Event
 		err error
 	)
 
-	ds, err := openDataset(path, evtid)
+	ds, err := openDataset(fsys, name, evtid)
 	if err != nil {
-		return evt, errors.Wrapf(err, "could not open resource %q", path)
+		return evt, errors.Wrapf(err, "could not open resource %q", name)
 	}
 	defer ds.Close()
 
-	evt, err = readEvent(ds.dir, evtid)
+	evt, err = readEvent(ds.fsys, ds.dir, evtid)
 	if err != nil {
 		return evt, errors.Wrapf(err, "could not read event")
 	}
 
-	fname := filepath.Join(ds.dir, evtid)
-	evt.Ps, err = readParticles(fname + "-particles.csv")
+	fname := path.Join(ds.dir, evtid)
+	evt.Ps, err = readParticles(ds.fsys, fname+"-particles.csv")
 	if err != nil {
 		return evt, errors.Wrapf(err, "could not read particles")
 	}
 
-	evt.Mcs, err = readMcTruth(fname + "-truth.csv")
+	evt.Mcs, err = readMcTruth(ds.fsys, fname+"-truth.csv")
 	if err != nil {
 		return evt, errors.Wrapf(err, "could not read truth")
 	}
 
+	evt.Blacklist, err = readBlacklist(ds.fsys, ds.dir, evtid)
+	if err != nil {
+		return evt, errors.Wrapf(err, "could not read blacklist")
+	}
+
 	return evt, err
 }
 
 // ReadEvent reads a complete Event value from the given path+prefix,
 // but without the Monte-Carlo informations.
+//
+// As for NewDataset, path may be a directory or an archive, and the CSV files
+// of the event may be compressed.
 func ReadEvent(path, evtid string) (Event, error) {
+	return ReadEventFS(osFS{}, path, evtid)
+}
+
+// ReadEventFS is like ReadEvent but reads the event from the named directory
+// or archive of fsys.
+func ReadEventFS(fsys fs.FS, name, evtid string) (Event, error) {
 	var (
 		evt Event
 		err error
 	)
 
-	ds, err := openDataset(path, evtid)
+	ds, err := openDataset(fsys, name, evtid)
 	if err != nil {
-		return evt, errors.Wrapf(err, "could not open resource %q", path)
+		return evt, errors.Wrapf(err, "could not open resource %q", name)
 	}
 	defer ds.Close()
 
-	return readEvent(ds.dir, evtid)
+	return readEvent(ds.fsys, ds.dir, evtid)
 }
 
+// datasetHandler gives access to the files of an event, in the directory dir
+// of fsys.
 type datasetHandler struct {
-	dir string
-	rm  func() error
+	fsys fs.FS
+	dir  string
+	rm   func() error
 }
 
 func (ds *datasetHandler) Close() error {
@@ -135,70 +172,80 @@ func (ds *datasetHandler) Close() error {
 	return nil
 }
 
-func openDataset(fname, evtid string) (datasetHandler, error) {
+// openDataset gives access to the files of an event of the named dataset of
+// fsys.
+// Files of zip archives are read in place, those of tar archives are
+// extracted to a temporary directory.
+func openDataset(fsys fs.FS, fname, evtid string) (datasetHandler, error) {
 	var (
-		ds  = datasetHandler{dir: fname}
+		ds  = datasetHandler{fsys: fsys, dir: fname}
 		err error
 	)
 
-	f, err := os.Open(fname)
+	f, err := fsys.Open(fname)
 	if err != nil {
 		return ds, err
 	}
-	defer f.Close()
 
 	fi, err := f.Stat()
 	if err != nil {
+		f.Close()
 		return ds, err
 	}
 
 	if fi.IsDir() {
+		f.Close()
 		return ds, nil
 	}
 
-	zr, err := zip.NewReader(f, fi.Size())
+	zr, r, err := openArchive(f, fname)
 	if err != nil {
-		return ds, errors.Wrapf(err, "could not open zip-dataset")
+		f.Close()
+		return ds, errors.Wrapf(err, "could not open archive dataset")
+	}
+	if zr != nil {
+		ds.fsys = zr
+		ds.dir = "."
+		ds.rm = f.Close
+		for _, zf := range zr.File {
+			if strings.HasPrefix(path.Base(zf.Name), evtid) {
+				ds.dir = path.Dir(path.Clean(zf.Name))
+				break
+			}
+		}
+		return ds, nil
 	}
+	defer f.Close()
+	defer r.Close()
 
 	tmpdir, err := ioutil.TempDir("", "trkml-")
 	if err != nil {
-		return ds, errors.Wrapf(err, "could not create temporary directory for zip-dataset")
+		return ds, errors.Wrapf(err, "could not create temporary directory for archive dataset")
 	}
+	ds.fsys = osFS{}
+	ds.dir = tmpdir
 	ds.rm = func() error {
 		return os.RemoveAll(tmpdir)
 	}
-	for _, f := range zr.File {
-		if !strings.HasPrefix(filepath.Base(f.Name), evtid) {
-			continue
-		}
-		oname := filepath.Join(tmpdir, f.Name)
-		err = os.MkdirAll(filepath.Dir(oname), 0755)
-		if err != nil {
-			ds.rm()
-			return ds, errors.Wrapf(err, "could not create base directory for output partial event file %q", oname)
-		}
-		o, err := os.Create(oname)
-		if err != nil {
-			ds.rm()
-			return ds, errors.Wrapf(err, "could not create temporary output partial event file %q", oname)
-		}
-		zf, err := f.Open()
-		if err != nil {
-			ds.rm()
-			return ds, errors.Wrapf(err, "could not open zip archive partial event file %q", f.Name)
+	err = walkTar(r, func(name string, r io.Reader) error {
+		if !strings.HasPrefix(path.Base(name), evtid) {
+			return nil
 		}
-		_, err = io.Copy(o, zf)
+		oname, err := extractFile(tmpdir, name, r)
 		if err != nil {
-			ds.rm()
-			return ds, errors.Wrapf(err, "could extract partial event file %q", f.Name)
+			return err
 		}
-		ds.dir = filepath.Dir(filepath.Join(tmpdir, f.Name))
+		ds.dir = filepath.Dir(oname)
+		return nil
+	})
+	if err != nil {
+		ds.rm()
+		return ds, errors.Wrapf(err, "could not open archive dataset")
 	}
 	return ds, nil
 }
 
-func readEvent(dir, evtid string) (Event, error) {
+func readEvent(fsys fs.FS, dir, evtid string) (Event, error) {
 	var (
 		evt Event
 		err error
@@ -210,13 +257,13 @@ func readEvent(dir, evtid string) (Event, error) {
 		return evt, errors.Wrapf(err, "could not infer event ID")
 	}
 
-	fname := filepath.Join(dir, evtid)
-	evt.Hits, err = readHits(fname + "-hits.csv")
+	fname := path.Join(dir, evtid)
+	evt.Hits, err = readHits(fsys, fname+"-hits.csv")
 	if err != nil {
 		return evt, errors.Wrapf(err, "could not read hits")
 	}
 
-	evt.Cells, err = readCells(fname + "-cells.csv")
+	evt.Cells, err = readCells(fsys, fname+"-cells.csv")
 	if err != nil {
 		return evt, errors.Wrapf(err, "could not read cells")
 	}
@@ -224,126 +271,6 @@ func readEvent(dir, evtid string) (Event, error) {
 	return evt, err
 }
 
-func readHits(fname string) ([]Hit, error) {
-	var hits []Hit
-	tbl, err := csvutil.Open(fname)
-	if err != nil {
-		return nil, errors.Wrapf(err, "could not open CSV file")
-	}
-	defer tbl.Close()
-
-	rows, err := tbl.ReadRows(1, -1) // skip header
-	if err != nil {
-		return nil, errors.Wrapf(err, "could not create row iterator")
-	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var hit Hit
-		err := rows.Scan(&hit)
-		if err != nil {
-			return nil, errors.Wrapf(err, "could not read row")
-		}
-		hits = append(hits, hit)
-	}
-
-	if err := rows.Err(); err != nil && err != io.EOF {
-		return nil, errors.Wrapf(err, "error during row iteration")
-	}
-
-	return hits, nil
-}
-
-func readCells(fname string) ([]Cell, error) {
-	var cells []Cell
-	tbl, err := csvutil.Open(fname)
-	if err != nil {
-		return nil, errors.Wrapf(err, "could not open CSV file")
-	}
-	defer tbl.Close()
-
-	rows, err := tbl.ReadRows(1, -1) // skip header
-	if err != nil {
-		return nil, errors.Wrapf(err, "could not create row iterator")
-	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var cell Cell
-		err := rows.Scan(&cell)
-		if err != nil {
-			return nil, errors.Wrapf(err, "could not read row")
-		}
-		cells = append(cells, cell)
-	}
-
-	if err := rows.Err(); err != nil && err != io.EOF {
-		return nil, errors.Wrapf(err, "error during row iteration")
-	}
-
-	return cells, nil
-}
-
-func readParticles(fname string) ([]Particle, error) {
-	var ps []Particle
-	tbl, err := csvutil.Open(fname)
-	if err != nil {
-		return nil, errors.Wrapf(err, "could not open CSV file")
-	}
-	defer tbl.Close()
-
-	rows, err := tbl.ReadRows(1, -1) // skip header
-	if err != nil {
-		return nil, errors.Wrapf(err, "could not create row iterator")
-	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var p Particle
-		err := rows.Scan(&p)
-		if err != nil {
-			return nil, errors.Wrapf(err, "could not read row")
-		}
-		ps = append(ps, p)
-	}
-
-	if err := rows.Err(); err != nil && err != io.EOF {
-		return nil, errors.Wrapf(err, "error during row iteration")
-	}
-
-	return ps, nil
-}
-
-func readMcTruth(fname string) ([]Truth, error) {
-	var mcs []Truth
-	tbl, err := csvutil.Open(fname)
-	if err != nil {
-		return nil, errors.Wrapf(err, "could not open CSV file")
-	}
-	defer tbl.Close()
-
-	rows, err := tbl.ReadRows(1, -1) // skip header
-	if err != nil {
-		return nil, errors.Wrapf(err, "could not create row iterator")
-	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var mc Truth
-		err := rows.Scan(&mc)
-		if err != nil {
-			return nil, errors.Wrapf(err, "could not read row")
-		}
-		mcs = append(mcs, mc)
-	}
-
-	if err := rows.Err(); err != nil && err != io.EOF {
-		return nil, errors.Wrapf(err, "error during row iteration")
-	}
-
-	return mcs, nil
-}
-
 // EventReader is a function to read an event from a path
 type EventReader func(path, evtid string) (Event, error)
 
@@ -365,6 +292,7 @@ type EventReader func(path, evtid string) (Event, error)
 type Dataset struct {
 	path  string
 	names []string
+	rm    func() error // removes the extracted files of tar archives
 
 	readEvent EventReader
 
@@ -374,6 +302,13 @@ type Dataset struct {
 }
 
 func (ds *Dataset) Close() error {
+	if ds.rm != nil {
+		err := ds.rm()
+		ds.rm = nil
+		if err != nil {
+			return errors.Wrapf(err, "could not cleanup temporary files")
+		}
+	}
 	if ds.err != nil {
 		return ds.err
 	}
@@ -381,6 +316,14 @@ func (ds *Dataset) Close() error {
 	return ds.err
 }
 
+// Path returns the path given to the reader function to load the events of
+// the dataset.
+// Tar archives are extracted once, in a temporary directory removed by Close:
+// their path is the local directory of the extracted events.
+func (ds *Dataset) Path() string {
+	return ds.path
+}
+
 // Names returns the list of event IDs this dataset contains.
 func (ds *Dataset) Names() []string {
 	return ds.names
@@ -415,9 +358,12 @@ func (ds *Dataset) Err() error {
 	return ds.err
 }
 
-// NewDataset returns the list of datasets from name, a directory or zip file,
-// containing many events data.
+// NewDataset returns the list of datasets from name, a directory, a zip file
+// or a (gzip- or zstd-compressed) tar file, containing many events data.
+// The CSV files of the events may be gzip- or zstd-compressed.
 //
+// Events are listed from their hits, cells, particles and truth files: an
+// event with missing files is listed, and fails to be read.
 // beg and end control the number of events to iterate over.
 //
 // The returned Dataset will use the reader function to load events from a path.
@@ -426,13 +372,23 @@ func NewDataset(name string, beg, end int, reader EventReader) (Dataset, error)
 	if reader == nil {
 		reader = ReadMcEvent
 	}
+	return NewDatasetFS(osFS{}, name, beg, end, reader)
+}
+
+// NewDatasetFS is like NewDataset but lists the events of the named dataset of
+// fsys.
+//
+// The reader function is given the name of the dataset in fsys or, for tar
+// archives, the local directory where the archive was extracted (see Path).
+// If reader is nil, ReadMcEventFS is used.
+func NewDatasetFS(fsys fs.FS, name string, beg, end int, reader EventReader) (Dataset, error) {
 	ds := Dataset{
 		path:      name,
 		cur:       -1,
 		readEvent: reader,
 	}
 
-	f, err := os.Open(name)
+	f, err := fsys.Open(name)
 	if err != nil {
 		return ds, errors.WithStack(err)
 	}
@@ -443,25 +399,62 @@ func NewDataset(name string, beg, end int, reader EventReader) (Dataset, error)
 		return ds, errors.WithStack(err)
 	}
 
-	var names []string
+	var (
+		names []string
+		seen  = make(map[string]bool)
+	)
+	add := func(fname string) {
+		name := eventName(trimCompressExt(fname))
+		if name == "" {
+			return
+		}
+		if seen[name] {
+			return
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+
 	switch {
 	case fi.IsDir():
-		names, err = filepath.Glob(filepath.Join(name, "*-hits.csv"))
+		fnames, err := fs.Glob(fsys, path.Join(name, "*.csv*"))
 		if err != nil {
-			return ds, err
+			return ds, errors.WithStack(err)
+		}
+		for _, fname := range fnames {
+			add(fname)
 		}
 	default:
-		zr, err := zip.NewReader(f, fi.Size())
+		zr, r, err := openArchive(f, name)
 		if err != nil {
 			return ds, errors.Wrapf(err, "could not handle path %q", name)
 		}
-		for _, f := range zr.File {
-			if !strings.HasSuffix(f.Name, "-hits.csv") {
-				continue
+		if zr != nil {
+			for _, zf := range zr.File {
+				add(zf.Name)
 			}
-			names = append(names, f.Name)
+			break
+		}
+
+		// tar archives can only be read sequentially: extract them once.
+		fnames, err := ds.extract(r)
+		r.Close()
+		if err != nil {
+			ds.Close()
+			return ds, errors.Wrapf(err, "could not handle path %q", name)
+		}
+		for _, fname := range fnames {
+			add(fname)
 		}
+		fsys = osFS{}
 	}
+
+	if ds.readEvent == nil {
+		ds.readEvent = func(name, evtid string) (Event, error) {
+			return ReadMcEventFS(fsys, name, evtid)
+		}
+	}
+
 	sort.Strings(names)
 	ds.names = names
 	ds.names = ds.names[beg:]
@@ -469,8 +462,45 @@ func NewDataset(name string, beg, end int, reader EventReader) (Dataset, error)
 		end = len(ds.names)
 	}
 	ds.names = ds.names[:end]
-	for i, n := range ds.names {
-		ds.names[i] = n[:len(n)-len("-hits.csv")]
-	}
 	return ds, nil
 }
+
+// eventName returns the path prefix of the event of the provided hits, cells,
+// particles or truth file name, or an empty string for other files.
+func eventName(fname string) string {
+	for _, file := range schemaFiles {
+		suffix := "-" + file.name + ".csv"
+		if strings.HasSuffix(fname, suffix) {
+			return fname[:len(fname)-len(suffix)]
+		}
+	}
+	return ""
+}
+
+// extract extracts the files of the tar archive r in a temporary directory,
+// removed by Close.
+// The path of the dataset is set to the directory of the extracted events.
+func (ds *Dataset) extract(r io.Reader) ([]string, error) {
+	tmpdir, err := ioutil.TempDir("", "trkml-")
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not create temporary directory for archive dataset")
+	}
+	ds.rm = func() error {
+		return os.RemoveAll(tmpdir)
+	}
+
+	var fnames []string
+	ds.path = tmpdir
+	err = walkTar(r, func(name string, r io.Reader) error {
+		oname, err := extractFile(tmpdir, name, r)
+		if err != nil {
+			return err
+		}
+		fnames = append(fnames, oname)
+		if strings.HasSuffix(trimCompressExt(oname), "-hits.csv") {
+			ds.path = filepath.Dir(oname)
+		}
+		return nil
+	})
+	return fnames, err
+}
@@ -0,0 +1,113 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package trackml
+
+import (
+	"github.com/pkg/errors"
+)
+
+// Module describes a detector module, as read from a detectors.csv file.
+//
+// The position of a point in global coordinates is related to its position
+// in the local (u,v,w) coordinates of the module by:
+//
+//	(x,y,z) = (Cx,Cy,Cz) + Rot * (u,v,w)
+type Module struct {
+	VolumeID int `csv:"volume_id"`
+	LayerID  int `csv:"layer_id"`
+	ModuleID int `csv:"module_id"`
+
+	// position of the center of the module (mm)
+	Cx float64 `csv:"cx"`
+	Cy float64 `csv:"cy"`
+	Cz float64 `csv:"cz"`
+
+	// rotation matrix from local to global coordinates.
+	RotXU float64 `csv:"rot_xu"`
+	RotXV float64 `csv:"rot_xv"`
+	RotXW float64 `csv:"rot_xw"`
+	RotYU float64 `csv:"rot_yu"`
+	RotYV float64 `csv:"rot_yv"`
+	RotYW float64 `csv:"rot_yw"`
+	RotZU float64 `csv:"rot_zu"`
+	RotZV float64 `csv:"rot_zv"`
+	RotZW float64 `csv:"rot_zw"`
+
+	HalfT  float64 `csv:"module_t"`     // half thickness of the module (mm)
+	MinHu  float64 `csv:"module_minhu"` // minimum half length of the module along u (mm)
+	MaxHu  float64 `csv:"module_maxhu"` // maximum half length of the module along u (mm)
+	Hv     float64 `csv:"module_hv"`    // half length of the module along v (mm)
+	PitchU float64 `csv:"pitch_u"`      // size of a cell along u (mm)
+	PitchV float64 `csv:"pitch_v"`      // size of a cell along v (mm)
+}
+
+// U returns the direction of the local u axis in global coordinates.
+func (m Module) U() [3]float64 { return [3]float64{m.RotXU, m.RotYU, m.RotZU} }
+
+// V returns the direction of the local v axis in global coordinates.
+func (m Module) V() [3]float64 { return [3]float64{m.RotXV, m.RotYV, m.RotZV} }
+
+// W returns the direction of the local w axis, normal to the module,
+// in global coordinates.
+func (m Module) W() [3]float64 { return [3]float64{m.RotXW, m.RotYW, m.RotZW} }
+
+// Local returns the (u,v,w) local coordinates of the global (x,y,z) position.
+func (m Module) Local(x, y, z float64) (u, v, w float64) {
+	x -= m.Cx
+	y -= m.Cy
+	z -= m.Cz
+	u = x*m.RotXU + y*m.RotYU + z*m.RotZU
+	v = x*m.RotXV + y*m.RotYV + z*m.RotZV
+	w = x*m.RotXW + y*m.RotYW + z*m.RotZW
+	return u, v, w
+}
+
+// Detector describes the geometry of the TrackML detector.
+type Detector struct {
+	Modules []Module
+
+	index map[moduleKey]int
+}
+
+type moduleKey struct {
+	vol, lay, mod int
+}
+
+// NewDetector returns a detector made of the provided modules.
+func NewDetector(modules []Module) *Detector {
+	det := &Detector{
+		Modules: modules,
+		index:   make(map[moduleKey]int, len(modules)),
+	}
+	for i, m := range modules {
+		det.index[moduleKey{m.VolumeID, m.LayerID, m.ModuleID}] = i
+	}
+	return det
+}
+
+// Module returns the module identified by the provided volume, layer and
+// module IDs.
+func (det *Detector) Module(vol, lay, mod int) (Module, bool) {
+	i, ok := det.index[moduleKey{vol, lay, mod}]
+	if !ok {
+		return Module{}, false
+	}
+	return det.Modules[i], true
+}
+
+// HitModule returns the module the provided hit belongs to.
+func (det *Detector) HitModule(hit Hit) (Module, bool) {
+	return det.Module(hit.VolumeID, hit.LayerID, hit.ModuleID)
+}
+
+// ReadDetector reads the detector geometry from the provided detectors.csv file.
+func ReadDetector(fname string) (*Detector, error) {
+	var mods []Module
+	err := ReadCSV(fname, &mods)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not read detector modules")
+	}
+	return NewDetector(mods), nil
+}
@@ -0,0 +1,123 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package display
+
+import (
+	"math"
+	"sort"
+
+	"github.com/sbinet/go-trackml"
+	"github.com/sbinet/go-trackml/layers"
+	"gonum.org/v1/plot/plotter"
+)
+
+// bounds is the extent of a detector layer in r and z.
+type bounds struct {
+	rmin, rmax float64
+	zmin, zmax float64
+}
+
+// layerBounds returns the extent of each layer of the detector, computed
+// from the centers and corners of its modules.
+func layerBounds(det *trackml.Detector) map[layers.Layer]bounds {
+	bs := make(map[layers.Layer]bounds)
+	for _, m := range det.Modules {
+		var (
+			l     = layers.Layer{Volume: m.VolumeID, Layer: m.LayerID}
+			b, ok = bs[l]
+			u     = m.U()
+			v     = m.V()
+		)
+		if !ok {
+			b = bounds{
+				rmin: math.Inf(+1), rmax: math.Inf(-1),
+				zmin: math.Inf(+1), zmax: math.Inf(-1),
+			}
+		}
+		for _, c := range [][2]float64{
+			{0, 0},
+			{-m.MinHu, -m.Hv}, {+m.MinHu, -m.Hv},
+			{-m.MaxHu, +m.Hv}, {+m.MaxHu, +m.Hv},
+		} {
+			var (
+				x = m.Cx + c[0]*u[0] + c[1]*v[0]
+				y = m.Cy + c[0]*u[1] + c[1]*v[1]
+				z = m.Cz + c[0]*u[2] + c[1]*v[2]
+				r = math.Hypot(x, y)
+			)
+			b.rmin = math.Min(b.rmin, r)
+			b.rmax = math.Max(b.rmax, r)
+			b.zmin = math.Min(b.zmin, z)
+			b.zmax = math.Max(b.zmax, z)
+		}
+		bs[l] = b
+	}
+	return bs
+}
+
+// sortedBounds returns the layer bounds in a deterministic order.
+func sortedBounds(det *trackml.Detector) []bounds {
+	bs := layerBounds(det)
+	keys := make([]layers.Layer, 0, len(bs))
+	for k := range bs {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Volume != keys[j].Volume {
+			return keys[i].Volume < keys[j].Volume
+		}
+		return keys[i].Layer < keys[j].Layer
+	})
+	o := make([]bounds, len(keys))
+	for i, k := range keys {
+		o[i] = bs[k]
+	}
+	return o
+}
+
+// outlinesXY returns the inner and outer circles of each detector layer,
+// in the x-y plane.
+func outlinesXY(det *trackml.Detector) []plotter.XYs {
+	const n = 180
+	circle := func(r float64) plotter.XYs {
+		xys := make(plotter.XYs, n+1)
+		for i := range xys {
+			a := 2 * math.Pi * float64(i) / n
+			xys[i] = plotter.XY{X: r * math.Cos(a), Y: r * math.Sin(a)}
+		}
+		return xys
+	}
+
+	var (
+		lines []plotter.XYs
+		radii = make(map[float64]bool)
+	)
+	for _, b := range sortedBounds(det) {
+		for _, r := range []float64{b.rmin, b.rmax} {
+			r = math.Round(r)
+			if radii[r] {
+				continue
+			}
+			radii[r] = true
+			lines = append(lines, circle(r))
+		}
+	}
+	return lines
+}
+
+// outlinesRZ returns the outline of each detector layer, in the r-z plane.
+func outlinesRZ(det *trackml.Detector) []plotter.XYs {
+	var lines []plotter.XYs
+	for _, b := range sortedBounds(det) {
+		lines = append(lines, plotter.XYs{
+			{X: b.zmin, Y: b.rmin},
+			{X: b.zmax, Y: b.rmin},
+			{X: b.zmax, Y: b.rmax},
+			{X: b.zmin, Y: b.rmax},
+			{X: b.zmin, Y: b.rmin},
+		})
+	}
+	return lines
+}
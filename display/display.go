@@ -0,0 +1,312 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package display draws the hits of an event in the transverse (x-y) and
+// longitudinal (r-z) projections.
+//
+// Hits are coloured by Monte-Carlo particle or by predicted track.
+// Detector layers may be outlined, and fake or split tracks highlighted.
+package display // import "github.com/sbinet/go-trackml/display"
+
+import (
+	"image/color"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sbinet/go-trackml"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/palette"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+)
+
+var (
+	noiseColor = color.Gray{Y: 200}
+	fakeColor  = color.RGBA{R: 220, A: 255}
+	splitColor = color.RGBA{B: 220, A: 255}
+)
+
+// Display draws the hits of an event.
+type Display struct {
+	Event    trackml.Event
+	Labels   []int             // track ID of each hit, or nil to use the Monte-Carlo particle IDs
+	Detector *trackml.Detector // detector geometry, to outline layers (may be nil)
+
+	// Highlight enables the highlighting of fake and split tracks.
+	// It requires labels and the Monte-Carlo truth of the event.
+	Highlight bool
+
+	Radius vg.Length // radius of the hit markers
+}
+
+// New returns a display of the provided event, with hits coloured by
+// labels, or by Monte-Carlo particle if labels is nil.
+func New(evt trackml.Event, labels []int) *Display {
+	return &Display{
+		Event:  evt,
+		Labels: labels,
+		Radius: vg.Points(0.8),
+	}
+}
+
+// projection describes a view of the event.
+type projection struct {
+	title, xlabel, ylabel string
+
+	hit      func(hit trackml.Hit) (x, y float64)      // coordinates of a hit
+	outlines func(det *trackml.Detector) []plotter.XYs // outlines of the detector layers
+}
+
+var (
+	xyView = projection{
+		title:  "x-y view",
+		xlabel: "x [mm]",
+		ylabel: "y [mm]",
+		hit: func(hit trackml.Hit) (float64, float64) {
+			return hit.X, hit.Y
+		},
+		outlines: outlinesXY,
+	}
+	rzView = projection{
+		title:  "r-z view",
+		xlabel: "z [mm]",
+		ylabel: "r [mm]",
+		hit: func(hit trackml.Hit) (float64, float64) {
+			return hit.Z, math.Hypot(hit.X, hit.Y)
+		},
+		outlines: outlinesRZ,
+	}
+)
+
+// XY returns the transverse view of the event.
+func (d *Display) XY() (*plot.Plot, error) {
+	return d.view(xyView)
+}
+
+// RZ returns the longitudinal view of the event.
+func (d *Display) RZ() (*plot.Plot, error) {
+	return d.view(rzView)
+}
+
+func (d *Display) view(proj projection) (*plot.Plot, error) {
+	if d.Labels != nil && len(d.Labels) != len(d.Event.Hits) {
+		return nil, errors.Errorf(
+			"display: invalid number of labels (%d), want %d",
+			len(d.Labels), len(d.Event.Hits),
+		)
+	}
+
+	p := plot.New()
+	p.Title.Text = proj.title
+	p.X.Label.Text = proj.xlabel
+	p.Y.Label.Text = proj.ylabel
+
+	if d.Detector != nil {
+		for _, xys := range proj.outlines(d.Detector) {
+			l, err := plotter.NewLine(xys)
+			if err != nil {
+				return nil, errors.Wrapf(err, "display: could not create layer outline")
+			}
+			l.Color = color.Gray{Y: 160}
+			l.Width = vg.Points(0.5)
+			p.Add(l)
+		}
+	}
+
+	hits := &scatter{
+		xys:    make(plotter.XYs, len(d.Event.Hits)),
+		colors: make([]color.Color, len(d.Event.Hits)),
+		style:  draw.GlyphStyle{Shape: draw.CircleGlyph{}, Radius: d.Radius},
+	}
+	for i, hit := range d.Event.Hits {
+		hits.xys[i].X, hits.xys[i].Y = proj.hit(hit)
+		hits.colors[i] = d.color(i)
+	}
+	p.Add(hits)
+
+	if d.Highlight && d.Labels != nil && len(d.Event.Mcs) == len(d.Event.Hits) {
+		fakes, splits := faults(d.Event, d.Labels)
+		for _, v := range []struct {
+			name   string
+			tracks map[int]bool
+			color  color.Color
+		}{
+			{"fake tracks", fakes, fakeColor},
+			{"split tracks", splits, splitColor},
+		} {
+			s := &scatter{
+				style: draw.GlyphStyle{Shape: draw.RingGlyph{}, Radius: 3 * d.Radius},
+			}
+			for i, hit := range d.Event.Hits {
+				if !v.tracks[d.Labels[i]] {
+					continue
+				}
+				x, y := proj.hit(hit)
+				s.xys = append(s.xys, plotter.XY{X: x, Y: y})
+				s.colors = append(s.colors, v.color)
+			}
+			p.Add(s)
+			p.Legend.Add(v.name, glyph{draw.GlyphStyle{
+				Shape:  draw.RingGlyph{},
+				Radius: vg.Points(3),
+				Color:  v.color,
+			}})
+		}
+		p.Legend.Top = true
+	}
+
+	return p, nil
+}
+
+// color returns the colour of the i-th hit.
+func (d *Display) color(i int) color.Color {
+	var label int
+	switch {
+	case d.Labels != nil:
+		label = d.Labels[i]
+		if label == trackml.Unassigned {
+			return noiseColor
+		}
+	case len(d.Event.Mcs) > i:
+		label = d.Event.Mcs[i].PID
+		if label == 0 {
+			return noiseColor
+		}
+	default:
+		return noiseColor
+	}
+	return labelColor(label)
+}
+
+// labelColor returns a colour for the provided label, with a hue spread
+// over the colour wheel by Fibonacci hashing.
+func labelColor(label int) color.Color {
+	h := uint64(label) * 0x9E3779B97F4A7C15
+	return palette.HSVA{
+		H: float64(h>>11) / (1 << 53),
+		S: 0.8,
+		V: 0.85,
+		A: 1,
+	}
+}
+
+// faults returns the fake and split tracks among the labeled tracks.
+//
+// Fake tracks do not share more than half of their hits with their majority
+// particle.
+// Split tracks share their majority particle with another track.
+func faults(evt trackml.Event, labels []int) (fakes, splits map[int]bool) {
+	var (
+		ms   = trackml.MatchTracks(evt, labels)
+		pids = make(map[int]int, len(ms))
+	)
+	fakes = make(map[int]bool)
+	splits = make(map[int]bool)
+	for _, m := range ms {
+		if !m.Good() {
+			fakes[m.TrackID] = true
+		}
+		if m.PID != 0 {
+			pids[m.PID]++
+		}
+	}
+	for _, m := range ms {
+		if m.PID != 0 && pids[m.PID] > 1 {
+			splits[m.TrackID] = true
+		}
+	}
+	return fakes, splits
+}
+
+// Render writes both views of the event, side by side, to w in the provided
+// format (png, svg, pdf, ...).
+func (d *Display) Render(w io.Writer, width, height vg.Length, format string) error {
+	xy, err := d.XY()
+	if err != nil {
+		return err
+	}
+	rz, err := d.RZ()
+	if err != nil {
+		return err
+	}
+
+	c, err := draw.NewFormattedCanvas(width, height, format)
+	if err != nil {
+		return errors.Wrapf(err, "display: could not create canvas")
+	}
+	plots := [][]*plot.Plot{{xy, rz}}
+	tiles := draw.Tiles{Rows: 1, Cols: 2, PadX: vg.Millimeter, PadY: vg.Millimeter}
+	canvases := plot.Align(plots, tiles, draw.New(c))
+	for j, p := range plots[0] {
+		p.Draw(canvases[0][j])
+	}
+
+	_, err = c.WriteTo(w)
+	if err != nil {
+		return errors.Wrapf(err, "display: could not write %s image", format)
+	}
+	return nil
+}
+
+// Save saves both views of the event to the named file.
+// The format of the file is deduced from its extension.
+func (d *Display) Save(width, height vg.Length, fname string) error {
+	format := strings.ToLower(strings.TrimPrefix(filepath.Ext(fname), "."))
+	f, err := os.Create(fname)
+	if err != nil {
+		return errors.Wrapf(err, "display: could not create output file")
+	}
+	defer f.Close()
+
+	err = d.Render(f, width, height, format)
+	if err != nil {
+		return err
+	}
+
+	err = f.Close()
+	if err != nil {
+		return errors.Wrapf(err, "display: could not close output file")
+	}
+	return nil
+}
+
+// scatter draws points with individual colours.
+type scatter struct {
+	xys    plotter.XYs
+	colors []color.Color
+	style  draw.GlyphStyle
+}
+
+// Plot implements plot.Plotter.
+func (s *scatter) Plot(c draw.Canvas, p *plot.Plot) {
+	trX, trY := p.Transforms(&c)
+	sty := s.style
+	for i, xy := range s.xys {
+		sty.Color = s.colors[i]
+		c.DrawGlyph(sty, vg.Point{X: trX(xy.X), Y: trY(xy.Y)})
+	}
+}
+
+// DataRange implements plot.DataRanger.
+func (s *scatter) DataRange() (xmin, xmax, ymin, ymax float64) {
+	if len(s.xys) == 0 {
+		return math.Inf(+1), math.Inf(-1), math.Inf(+1), math.Inf(-1)
+	}
+	return plotter.XYRange(s.xys)
+}
+
+// glyph is a legend thumbnail.
+type glyph struct {
+	style draw.GlyphStyle
+}
+
+// Thumbnail implements plot.Thumbnailer.
+func (g glyph) Thumbnail(c *draw.Canvas) {
+	c.DrawGlyph(g.style, c.Center())
+}
@@ -0,0 +1,139 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package display
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/sbinet/go-trackml"
+	"github.com/sbinet/go-trackml/hough"
+	"github.com/sbinet/go-trackml/layers"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+	"gonum.org/v1/plot/vg/vgimg"
+)
+
+func newEvent() trackml.Event {
+	var evt trackml.Event
+	for i := 0; i < 12; i++ {
+		var (
+			pid = 1 + i/4
+			r   = 100 * float64(1+i%4)
+			phi = float64(pid)
+		)
+		evt.Hits = append(evt.Hits, trackml.Hit{
+			HitID: i + 1,
+			X:     r * math.Cos(phi),
+			Y:     r * math.Sin(phi),
+			Z:     r,
+		})
+		evt.Mcs = append(evt.Mcs, trackml.Truth{HitID: i + 1, PID: pid, Weight: 1})
+	}
+	return evt
+}
+
+func TestFaults(t *testing.T) {
+	evt := newEvent()
+	labels := []int{
+		0, 0, 0, 0, // good track.
+		1, 1, 2, 2, // particle 2 split over 2 fake tracks.
+		4, 4, 0, 3, // particle 3 split over 2 fake tracks, one hit given away.
+	}
+
+	fakes, splits := faults(evt, labels)
+	if want := map[int]bool{1: true, 2: true, 3: true, 4: true}; !reflect.DeepEqual(fakes, want) {
+		t.Fatalf("invalid fake tracks\ngot = %v\nwant= %v", fakes, want)
+	}
+	if want := map[int]bool{1: true, 2: true, 3: true, 4: true}; !reflect.DeepEqual(splits, want) {
+		t.Fatalf("invalid split tracks\ngot = %v\nwant= %v", splits, want)
+	}
+
+	labels = []int{
+		0, 0, 0, 0,
+		1, 1, 1, trackml.Unassigned,
+		2, 2, 2, 5,
+	}
+	fakes, splits = faults(evt, labels)
+	if want := map[int]bool{5: true}; !reflect.DeepEqual(fakes, want) {
+		t.Fatalf("invalid fake tracks\ngot = %v\nwant= %v", fakes, want)
+	}
+	if want := map[int]bool{2: true, 5: true}; !reflect.DeepEqual(splits, want) {
+		t.Fatalf("invalid split tracks\ngot = %v\nwant= %v", splits, want)
+	}
+}
+
+func TestLayerBounds(t *testing.T) {
+	det := trackml.NewDetector([]trackml.Module{{
+		VolumeID: 8, LayerID: 2, ModuleID: 1,
+		Cx: 100, Cz: 50,
+		RotYU: 1, RotZV: 1, RotXW: 1,
+		MinHu: 10, MaxHu: 10, Hv: 20,
+	}})
+	got := layerBounds(det)
+	want := map[layers.Layer]bounds{
+		{Volume: 8, Layer: 2}: {rmin: 100, rmax: math.Hypot(100, 10), zmin: 30, zmax: 70},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("invalid bounds\ngot = %+v\nwant= %+v", got, want)
+	}
+}
+
+func TestRender(t *testing.T) {
+	evt := newEvent()
+	det := trackml.NewDetector([]trackml.Module{{
+		VolumeID: 8, LayerID: 2, ModuleID: 1,
+		Cx: 100, RotYU: 1, RotZV: 1, RotXW: 1,
+		MinHu: 10, MaxHu: 10, Hv: 20,
+	}})
+
+	for _, tc := range []struct {
+		format string
+		magic  string
+	}{
+		{"png", "\x89PNG"},
+		{"svg", "<?xml"},
+	} {
+		t.Run(tc.format, func(t *testing.T) {
+			d := New(evt, []int{0, 0, 0, 0, 1, 1, 2, 2, 3, 3, 3, trackml.Unassigned})
+			d.Detector = det
+			d.Highlight = true
+
+			buf := new(bytes.Buffer)
+			err := d.Render(buf, 20*vg.Centimeter, 10*vg.Centimeter, tc.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.HasPrefix(buf.Bytes(), []byte(tc.magic)) {
+				t.Fatalf("invalid %s output: %q", tc.format, buf.Bytes()[:8])
+			}
+		})
+	}
+
+	d := New(evt, []int{0})
+	err := d.Render(new(bytes.Buffer), 20*vg.Centimeter, 10*vg.Centimeter, "png")
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+}
+
+func TestHough(t *testing.T) {
+	evt := newEvent()
+	s := hough.New(evt.Hits).Snapshot(0.5, 200, 500)
+
+	p, err := Hough(s, evt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := vgimg.New(20*vg.Centimeter, 20*vg.Centimeter)
+	p.Draw(draw.New(c))
+
+	_, err = Hough(s, trackml.Event{})
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+}
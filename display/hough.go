@@ -0,0 +1,145 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package display
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/pkg/errors"
+	"github.com/sbinet/go-trackml"
+	"github.com/sbinet/go-trackml/hough"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/palette"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+)
+
+// Hough returns the heat map of the number of hits in each bin of the
+// Hough accumulator, in the fiducial range.
+//
+// The hits of the Monte-Carlo particles of the event are overlaid, coloured
+// by particle, if the event holds Monte-Carlo truth.
+// The axes span the bins holding hits.
+// The hits of the event must be the ones the accumulator was filled with.
+func Hough(s hough.Snapshot, evt trackml.Event) (*plot.Plot, error) {
+	if len(evt.Hits) != len(s.HitIDs) {
+		return nil, errors.Errorf(
+			"display: invalid number of hits (%d), want %d",
+			len(evt.Hits), len(s.HitIDs),
+		)
+	}
+
+	p := plot.New()
+	p.Title.Text = fmt.Sprintf("Hough accumulator (theta=%.4f)", s.Theta)
+	p.X.Label.Text = "r0inv [1/mm]"
+	p.Y.Label.Text = "gamma"
+
+	g := newAccumulator(s)
+	if g.max > 0 {
+		hm := plotter.NewHeatMap(g, palette.Heat(16, 1))
+		hm.NaN = color.Transparent
+		hm.Min = 0
+		hm.Rasterized = true
+		p.Add(hm)
+	}
+
+	if len(evt.Mcs) == len(evt.Hits) {
+		hits := &scatter{
+			style: draw.GlyphStyle{Shape: draw.CircleGlyph{}, Radius: vg.Points(1)},
+		}
+		for i, mc := range evt.Mcs {
+			if mc.PID == 0 {
+				continue
+			}
+			hits.xys = append(hits.xys, plotter.XY{X: s.R0Inv[i], Y: s.Gamma[i]})
+			hits.colors = append(hits.colors, labelColor(mc.PID))
+		}
+		p.Add(hits)
+	}
+
+	// zoom on the populated bins.
+	var (
+		cmin, cmax = s.NBinsR0Inv, 0
+		rmin, rmax = s.NBinsGamma, 0
+	)
+	for i := range s.HitIDs {
+		c, r := s.R0InvDigi[i], s.GammaDigi[i]
+		if c <= 0 || c >= s.NBinsR0Inv || r <= 0 || r >= s.NBinsGamma {
+			continue
+		}
+		cmin, cmax = imin(cmin, c), imax(cmax, c)
+		rmin, rmax = imin(rmin, r), imax(rmax, r)
+	}
+	if cmin <= cmax {
+		p.X.Min, _ = s.R0InvBin(cmin)
+		_, p.X.Max = s.R0InvBin(cmax)
+		p.Y.Min, _ = s.GammaBin(rmin)
+		_, p.Y.Max = s.GammaBin(rmax)
+	}
+	return p, nil
+}
+
+func imin(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func imax(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// accumulator is the grid of the inner bins of a Hough accumulator.
+// Empty bins are NaN.
+type accumulator struct {
+	s    hough.Snapshot
+	bins [][]int
+	max  int
+}
+
+func newAccumulator(s hough.Snapshot) *accumulator {
+	acc := &accumulator{s: s, bins: s.Bins()}
+	for _, row := range acc.bins[1:s.NBinsR0Inv] {
+		for _, n := range row[1:s.NBinsGamma] {
+			if n > acc.max {
+				acc.max = n
+			}
+		}
+	}
+	return acc
+}
+
+// Dims implements plotter.GridXYZ.
+func (acc *accumulator) Dims() (c, r int) {
+	return acc.s.NBinsR0Inv - 1, acc.s.NBinsGamma - 1
+}
+
+// Z implements plotter.GridXYZ.
+func (acc *accumulator) Z(c, r int) float64 {
+	n := acc.bins[c+1][r+1]
+	if n == 0 {
+		return math.NaN()
+	}
+	return float64(n)
+}
+
+// X implements plotter.GridXYZ.
+func (acc *accumulator) X(c int) float64 {
+	lo, hi := acc.s.R0InvBin(c + 1)
+	return 0.5 * (lo + hi)
+}
+
+// Y implements plotter.GridXYZ.
+func (acc *accumulator) Y(r int) float64 {
+	lo, hi := acc.s.GammaBin(r + 1)
+	return 0.5 * (lo + hi)
+}
@@ -0,0 +1,170 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package eval
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/pkg/errors"
+	"gonum.org/v1/gonum/stat"
+	"gonum.org/v1/gonum/stat/distuv"
+)
+
+// Interval is a confidence interval on a mean.
+type Interval struct {
+	Mean  float64 // mean of the sample
+	Lo    float64 // lower bound
+	Hi    float64 // upper bound
+	Level float64 // confidence level
+}
+
+// Contains returns whether v is within the interval.
+func (iv Interval) Contains(v float64) bool {
+	return iv.Lo <= v && v <= iv.Hi
+}
+
+// Comparison is the paired comparison of the per-event scores of two models,
+// a and b.
+type Comparison struct {
+	Diffs  []float64 // per-event score differences, a-b
+	Diff   Interval  // bootstrap interval on the mean difference
+	Wins   int       // number of events where a scores higher than b
+	Losses int       // number of events where a scores lower than b
+	Ties   int       // number of events where a and b score the same
+	SignP  float64   // two-sided p-value of the sign test
+	BootP  float64   // two-sided p-value of the paired bootstrap
+}
+
+// Bootstrap estimates the uncertainty on the mean score over events by
+// resampling the events, with replacement.
+type Bootstrap struct {
+	N     int     // number of resamples
+	Level float64 // confidence level of the intervals
+	Seed  int64   // seed of the resampling
+}
+
+// NewBootstrap returns a bootstrap with 10000 resamples, for 95% confidence
+// intervals.
+func NewBootstrap() Bootstrap {
+	return Bootstrap{N: 10000, Level: 0.95, Seed: 1234}
+}
+
+// Mean returns the percentile bootstrap interval on the mean of the
+// provided scores.
+func (b Bootstrap) Mean(scores []float64) Interval {
+	means := b.resample(scores)
+	return b.interval(stat.Mean(scores, nil), means)
+}
+
+// Compare compares the per-event scores of two models, evaluated on the
+// same events in the same order.
+//
+// The mean difference is given a paired bootstrap interval, resampling the
+// per-event differences, and the number of events where each model scores
+// higher is checked against a fair coin with an exact sign test.
+func (b Bootstrap) Compare(as, bs []float64) (Comparison, error) {
+	var cmp Comparison
+	if len(as) != len(bs) {
+		return cmp, errors.Errorf("eval: number of scores differ (%d != %d)", len(as), len(bs))
+	}
+	if len(as) == 0 {
+		return cmp, errors.Errorf("eval: no score to compare")
+	}
+
+	cmp.Diffs = make([]float64, len(as))
+	for i := range as {
+		d := as[i] - bs[i]
+		cmp.Diffs[i] = d
+		switch {
+		case d > 0:
+			cmp.Wins++
+		case d < 0:
+			cmp.Losses++
+		default:
+			cmp.Ties++
+		}
+	}
+
+	means := b.resample(cmp.Diffs)
+	cmp.Diff = b.interval(stat.Mean(cmp.Diffs, nil), means)
+	cmp.SignP = signTest(cmp.Wins, cmp.Losses)
+
+	// fraction of resampled mean differences on the other side of zero.
+	n := 0
+	for _, m := range means {
+		if (cmp.Diff.Mean > 0 && m <= 0) || (cmp.Diff.Mean < 0 && m >= 0) || cmp.Diff.Mean == 0 {
+			n++
+		}
+	}
+	cmp.BootP = math.NaN()
+	if len(means) > 0 {
+		cmp.BootP = math.Min(1, 2*float64(n)/float64(len(means)))
+	}
+
+	return cmp, nil
+}
+
+// resample returns the sorted means of b.N resamples of vs.
+func (b Bootstrap) resample(vs []float64) []float64 {
+	if len(vs) == 0 || b.N <= 0 {
+		return nil
+	}
+	var (
+		rnd   = rand.New(rand.NewSource(b.Seed))
+		means = make([]float64, b.N)
+	)
+	for i := range means {
+		sum := 0.0
+		for range vs {
+			sum += vs[rnd.Intn(len(vs))]
+		}
+		means[i] = sum / float64(len(vs))
+	}
+	sort.Float64s(means)
+	return means
+}
+
+func (b Bootstrap) interval(mean float64, means []float64) Interval {
+	if len(means) == 0 {
+		return Interval{Mean: mean, Lo: math.NaN(), Hi: math.NaN(), Level: b.Level}
+	}
+	alpha := 0.5 * (1 - b.Level)
+	return Interval{
+		Mean:  mean,
+		Lo:    stat.Quantile(alpha, stat.Empirical, means, nil),
+		Hi:    stat.Quantile(1-alpha, stat.Empirical, means, nil),
+		Level: b.Level,
+	}
+}
+
+// signTest returns the two-sided p-value of the exact sign test, ties being
+// discarded.
+func signTest(wins, losses int) float64 {
+	n := wins + losses
+	if n == 0 {
+		return 1
+	}
+	k := wins
+	if losses < k {
+		k = losses
+	}
+	bin := distuv.Binomial{N: float64(n), P: 0.5}
+	return math.Min(1, 2*bin.CDF(float64(k)))
+}
+
+// Scores returns the scores of the provided results, skipping the events
+// without Monte-Carlo truth.
+func Scores(res []Result) []float64 {
+	scores := make([]float64, 0, len(res))
+	for _, r := range res {
+		if math.IsNaN(r.Score) {
+			continue
+		}
+		scores = append(scores, r.Score)
+	}
+	return scores
+}
@@ -0,0 +1,129 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package eval
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestSignTest(t *testing.T) {
+	for _, tc := range []struct {
+		wins, losses int
+		want         float64
+	}{
+		{0, 0, 1},
+		{5, 0, 0.0625},
+		{0, 5, 0.0625},
+		{3, 2, 1},
+		{8, 2, 0.109375},
+		{10, 0, 2.0 / 1024},
+	} {
+		t.Run(fmt.Sprintf("%d-%d", tc.wins, tc.losses), func(t *testing.T) {
+			got := signTest(tc.wins, tc.losses)
+			if math.Abs(got-tc.want) > 1e-12 {
+				t.Fatalf("invalid p-value: got=%v, want=%v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestBootstrapMean(t *testing.T) {
+	b := NewBootstrap()
+
+	iv := b.Mean([]float64{0.5, 0.5, 0.5})
+	if iv.Mean != 0.5 || iv.Lo != 0.5 || iv.Hi != 0.5 {
+		t.Fatalf("invalid interval: %+v", iv)
+	}
+
+	const n = 200
+	var (
+		rnd    = rand.New(rand.NewSource(42))
+		scores = make([]float64, n)
+	)
+	for i := range scores {
+		scores[i] = 0.5 + 0.1*rnd.NormFloat64()
+	}
+
+	iv = b.Mean(scores)
+	if !iv.Contains(iv.Mean) || !iv.Contains(0.5) {
+		t.Fatalf("invalid interval: %+v", iv)
+	}
+	// the half-width should be close to 1.96 sigma/sqrt(n).
+	want := 1.96 * 0.1 / math.Sqrt(n)
+	if got := 0.5 * (iv.Hi - iv.Lo); math.Abs(got-want) > 0.2*want {
+		t.Fatalf("invalid half-width: got=%v, want=%v", got, want)
+	}
+
+	if again := b.Mean(scores); again != iv {
+		t.Fatalf("bootstrap is not reproducible:\ngot = %+v\nwant= %+v", again, iv)
+	}
+
+	iv = b.Mean(nil)
+	if !math.IsNaN(iv.Lo) || !math.IsNaN(iv.Hi) {
+		t.Fatalf("invalid interval of empty sample: %+v", iv)
+	}
+}
+
+func TestBootstrapCompare(t *testing.T) {
+	b := NewBootstrap()
+
+	_, err := b.Compare([]float64{1, 2}, []float64{1})
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	_, err = b.Compare(nil, nil)
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+
+	var (
+		rnd = rand.New(rand.NewSource(42))
+		as  = make([]float64, 10)
+		bs  = make([]float64, 10)
+	)
+	for i := range as {
+		as[i] = 0.5 + 0.1*rnd.NormFloat64()
+		bs[i] = as[i] - 0.003
+	}
+
+	// a consistently better than b, by a small amount.
+	cmp, err := b.Compare(as, bs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cmp.Wins != 10 || cmp.Losses != 0 || cmp.Ties != 0 {
+		t.Fatalf("invalid wins/losses/ties: %d/%d/%d", cmp.Wins, cmp.Losses, cmp.Ties)
+	}
+	if math.Abs(cmp.Diff.Mean-0.003) > 1e-12 || cmp.Diff.Lo <= 0 {
+		t.Fatalf("invalid interval: %+v", cmp.Diff)
+	}
+	if math.Abs(cmp.SignP-2.0/1024) > 1e-12 || cmp.BootP != 0 {
+		t.Fatalf("invalid p-values: sign=%v, boot=%v", cmp.SignP, cmp.BootP)
+	}
+
+	// a and b differ by noise.
+	for i := range bs {
+		bs[i] = as[i] + 0.01*rnd.NormFloat64()
+	}
+	cmp, err = b.Compare(as, bs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Diff.Contains(0) || cmp.SignP < 0.05 || cmp.BootP < 0.05 {
+		t.Fatalf("invalid comparison: %+v", cmp)
+	}
+
+	// identical models.
+	cmp, err = b.Compare(as, as)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cmp.Ties != 10 || cmp.SignP != 1 || cmp.BootP != 1 || cmp.Diff.Lo != 0 || cmp.Diff.Hi != 0 {
+		t.Fatalf("invalid comparison: %+v", cmp)
+	}
+}
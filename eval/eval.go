@@ -0,0 +1,232 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package eval evaluates track classifiers on the events of a dataset,
+// processing many events concurrently.
+package eval
+
+import (
+	"math"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sbinet/go-trackml"
+	"github.com/sbinet/go-trackml/clustering"
+	"gonum.org/v1/gonum/stat"
+)
+
+// Model returns a classifier using n goroutines to cluster the hits of an
+// event.
+// Classifiers implementing clustering.EventClassifier are given the whole
+// event.
+type Model func(n int) clustering.Classifier
+
+// Result is the evaluation of a classifier on an event.
+type Result struct {
+	Event   int           // event ID
+	Hits    int           // number of hits
+	Score   float64       // NaN for events without Monte-Carlo truth
+	Read    time.Duration // time to read the event
+	Predict time.Duration // time to cluster the hits of the event
+}
+
+// Summary describes the scores and timings of the evaluated events.
+// Scores are summarized over the events with Monte-Carlo truth.
+type Summary struct {
+	Events  int // number of events
+	Scored  int // number of events with a score
+	Mean    float64
+	Median  float64
+	StdDev  float64
+	Min     float64
+	Max     float64
+	Read    time.Duration // total time spent reading events
+	Predict time.Duration // total time spent clustering hits
+	Elapsed time.Duration // wall-clock time of the evaluation
+}
+
+// Runner evaluates a classifier on the events of a dataset.
+//
+// The Workers goroutines of the budget are shared among Events events
+// processed concurrently, each event being clustered with Workers/Events
+// goroutines.
+type Runner struct {
+	Model   Model
+	Workers int // total number of goroutines (NumCPU if <= 0)
+	Events  int // number of events processed concurrently (Workers if <= 0)
+
+	// Reader reads the events of the dataset.
+	// If nil, trackml.ReadMcEvent is used.
+	Reader trackml.EventReader
+
+	// Emit, if not nil, is called with each event and its predicted
+	// labels, one call at a time, in completion order.
+	Emit func(evt trackml.Event, labels []int) error
+}
+
+// New returns a runner of the provided model with a budget of n goroutines,
+// processing events one at a time.
+func New(model Model, n int) *Runner {
+	return &Runner{Model: model, Workers: n, Events: 1}
+}
+
+// budget returns the number of events processed concurrently and the number
+// of goroutines used for each of them, for a dataset of n events.
+func (r *Runner) budget(n int) (events, workers int) {
+	total := r.Workers
+	if total <= 0 {
+		total = runtime.NumCPU()
+	}
+	events = r.Events
+	if events <= 0 || events > total {
+		events = total
+	}
+	if events > n {
+		events = n
+	}
+	if events < 1 {
+		events = 1
+	}
+	workers = total / events
+	if workers < 1 {
+		workers = 1
+	}
+	return events, workers
+}
+
+// Run evaluates the events of the dataset at path, selected by beg and end
+// as for trackml.NewDataset.
+// Results are returned in the order of the events of the dataset.
+func (r *Runner) Run(path string, beg, end int) ([]Result, Summary, error) {
+	start := time.Now()
+
+	ds, err := trackml.NewDataset(path, beg, end, nil)
+	if err != nil {
+		return nil, Summary{}, errors.Wrapf(err, "eval: could not open dataset %q", path)
+	}
+	defer ds.Close()
+
+	reader := r.Reader
+	if reader == nil {
+		reader = trackml.ReadMcEvent
+	}
+
+	var (
+		names       = ds.Names()
+		res         = make([]Result, len(names))
+		errs        = make([]error, len(names))
+		nevts, nwrk = r.budget(len(names))
+
+		ch   = make(chan int)
+		quit = make(chan struct{}) // closed when an event fails
+		once sync.Once
+		mu   sync.Mutex // serializes calls to Emit
+		grp  sync.WaitGroup
+	)
+
+	grp.Add(nevts)
+	for i := 0; i < nevts; i++ {
+		model := r.Model(nwrk)
+		go func() {
+			defer grp.Done()
+			for i := range ch {
+				res[i], errs[i] = r.event(model, reader, ds.Path(), filepath.Base(names[i]), &mu)
+				if errs[i] != nil {
+					once.Do(func() { close(quit) })
+				}
+			}
+		}()
+	}
+loop:
+	for i := range names {
+		select {
+		case ch <- i:
+		case <-quit:
+			break loop
+		}
+	}
+	close(ch)
+	grp.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return nil, Summary{}, errors.Wrapf(err, "eval: could not evaluate event %q", filepath.Base(names[i]))
+		}
+	}
+
+	sum := Summarize(res)
+	sum.Elapsed = time.Since(start)
+	return res, sum, nil
+}
+
+func (r *Runner) event(model clustering.Classifier, reader trackml.EventReader, path, evtid string, mu *sync.Mutex) (Result, error) {
+	var res Result
+
+	start := time.Now()
+	evt, err := reader(path, evtid)
+	if err != nil {
+		return res, err
+	}
+	defer evt.Delete()
+	res.Event = evt.ID
+	res.Hits = len(evt.Hits)
+	res.Read = time.Since(start)
+
+	start = time.Now()
+	labels, err := clustering.PredictEvent(model, evt)
+	if err != nil {
+		return res, errors.Wrapf(err, "could not predict event")
+	}
+	res.Predict = time.Since(start)
+
+	if r.Emit != nil {
+		mu.Lock()
+		err = r.Emit(evt, labels)
+		mu.Unlock()
+		if err != nil {
+			return res, err
+		}
+	}
+
+	res.Score = math.NaN()
+	if len(evt.Mcs) > 0 {
+		res.Score = trackml.Score(evt, labels)
+	}
+	return res, nil
+}
+
+// Summarize returns the summary of the provided results.
+// The elapsed time is left to zero.
+func Summarize(res []Result) Summary {
+	sum := Summary{Events: len(res)}
+	for _, r := range res {
+		sum.Read += r.Read
+		sum.Predict += r.Predict
+	}
+
+	scores := Scores(res)
+	sum.Scored = len(scores)
+	if len(scores) == 0 {
+		return sum
+	}
+	sort.Float64s(scores)
+
+	n := len(scores)
+	sum.Min = scores[0]
+	sum.Max = scores[n-1]
+	sum.Median = scores[n/2]
+	if n%2 == 0 {
+		sum.Median = 0.5 * (scores[n/2-1] + scores[n/2])
+	}
+
+	sum.Mean = stat.Mean(scores, nil)
+	if n > 1 {
+		sum.StdDev = stat.StdDev(scores, nil)
+	}
+	return sum
+}
@@ -0,0 +1,239 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package eval
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/sbinet/go-trackml"
+	"github.com/sbinet/go-trackml/clustering"
+	"github.com/sbinet/go-trackml/internal/testevent"
+)
+
+func TestBudget(t *testing.T) {
+	for _, tc := range []struct {
+		workers, events, n int
+		wantEvts, wantWrks int
+	}{
+		{workers: 8, events: 1, n: 10, wantEvts: 1, wantWrks: 8},
+		{workers: 8, events: 2, n: 10, wantEvts: 2, wantWrks: 4},
+		{workers: 8, events: 3, n: 10, wantEvts: 3, wantWrks: 2},
+		{workers: 8, events: 0, n: 10, wantEvts: 8, wantWrks: 1},
+		{workers: 8, events: 16, n: 10, wantEvts: 8, wantWrks: 1},
+		{workers: 8, events: 4, n: 2, wantEvts: 2, wantWrks: 4},
+		{workers: 8, events: 4, n: 0, wantEvts: 1, wantWrks: 8},
+	} {
+		t.Run(fmt.Sprintf("%d-%d-%d", tc.workers, tc.events, tc.n), func(t *testing.T) {
+			r := Runner{Workers: tc.workers, Events: tc.events}
+			evts, wrks := r.budget(tc.n)
+			if evts != tc.wantEvts || wrks != tc.wantWrks {
+				t.Fatalf("invalid budget: got=(%d, %d), want=(%d, %d)", evts, wrks, tc.wantEvts, tc.wantWrks)
+			}
+		})
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	for _, tc := range []struct {
+		scores []float64
+		want   Summary
+	}{
+		{
+			scores: nil,
+			want:   Summary{},
+		},
+		{
+			scores: []float64{0.5},
+			want:   Summary{Events: 1, Scored: 1, Mean: 0.5, Median: 0.5, Min: 0.5, Max: 0.5},
+		},
+		{
+			scores: []float64{0.75, 0.25, 0.5},
+			want:   Summary{Events: 3, Scored: 3, Mean: 0.5, Median: 0.5, StdDev: 0.25, Min: 0.25, Max: 0.75},
+		},
+		{
+			scores: []float64{1, 0, 0.5, 0.25},
+			want:   Summary{Events: 4, Scored: 4, Mean: 0.4375, Median: 0.375, StdDev: math.Sqrt(0.546875 / 3), Min: 0, Max: 1},
+		},
+		{
+			// events without truth are not scored.
+			scores: []float64{math.NaN(), 0.75, math.NaN(), 0.25},
+			want:   Summary{Events: 4, Scored: 2, Mean: 0.5, Median: 0.5, StdDev: math.Sqrt(0.125), Min: 0.25, Max: 0.75},
+		},
+		{
+			scores: []float64{math.NaN(), math.NaN()},
+			want:   Summary{Events: 2},
+		},
+	} {
+		t.Run(fmt.Sprintf("n=%d", len(tc.scores)), func(t *testing.T) {
+			res := make([]Result, len(tc.scores))
+			for i, v := range tc.scores {
+				res[i].Score = v
+			}
+			got := Summarize(res)
+			if math.Abs(got.StdDev-tc.want.StdDev) > 1e-12 {
+				t.Fatalf("invalid std-dev: got=%v, want=%v", got.StdDev, tc.want.StdDev)
+			}
+			got.StdDev = tc.want.StdDev
+			if got != tc.want {
+				t.Fatalf("invalid summary:\ngot = %+v\nwant= %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "trkml-eval-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	const nevts = 7
+	for i := 1; i <= nevts; i++ {
+		_, err := testevent.New(10*i, 5).Write(dir, fmt.Sprintf("event%09d", i))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var (
+		cur, max int32
+		mu       sync.Mutex
+		emitted  = make(map[int]int)
+	)
+	r := &Runner{
+		Model: func(n int) clustering.Classifier {
+			if n != 2 {
+				t.Errorf("invalid number of workers: got=%d, want=2", n)
+			}
+			return perfect{&cur, &max}
+		},
+		Workers: 6,
+		Events:  3,
+		Emit: func(evt trackml.Event, labels []int) error {
+			mu.Lock()
+			defer mu.Unlock()
+			emitted[evt.ID] = len(labels)
+			return nil
+		},
+	}
+
+	res, sum, err := r.Run(dir, 0, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != nevts {
+		t.Fatalf("invalid number of results: got=%d, want=%d", len(res), nevts)
+	}
+	for i, res := range res {
+		if res.Event != i+1 || res.Hits != 10*(i+1) {
+			t.Fatalf("invalid result #%d: %+v", i, res)
+		}
+		if math.Abs(res.Score-1) > 1e-12 {
+			t.Fatalf("invalid score for event %d: got=%v, want=1", res.Event, res.Score)
+		}
+		if emitted[res.Event] != res.Hits {
+			t.Fatalf("event %d not emitted", res.Event)
+		}
+	}
+	if sum.Events != nevts || math.Abs(sum.Mean-1) > 1e-12 || math.Abs(sum.Median-1) > 1e-12 || sum.StdDev > 1e-12 {
+		t.Fatalf("invalid summary: %+v", sum)
+	}
+	if max > 3 {
+		t.Fatalf("too many concurrent events: got=%d, want<=3", max)
+	}
+
+	// events without truth are not scored.
+	r.Model = func(n int) clustering.Classifier { return perfect{&cur, &max} }
+	r.Reader = trackml.ReadEvent
+	res, _, err = r.Run(dir, 0, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 2 || !math.IsNaN(res[0].Score) {
+		t.Fatalf("invalid results: %+v", res)
+	}
+
+	// event classifiers are given the cells of the events.
+	r.Model = func(n int) clustering.Classifier { return cellCounter{} }
+	r.Reader = trackml.ReadMcEvent
+	res, _, err = r.Run(dir, 0, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 2 || math.Abs(res[0].Score-1) > 1e-12 {
+		t.Fatalf("invalid results: %+v", res)
+	}
+
+	// no more events are processed once an event fails.
+	var nread int32
+	r.Events = 1
+	r.Reader = func(path, evtid string) (trackml.Event, error) {
+		atomic.AddInt32(&nread, 1)
+		if evtid == "event000000002" {
+			return trackml.Event{}, fmt.Errorf("boom")
+		}
+		return trackml.ReadMcEvent(path, evtid)
+	}
+	_, _, err = r.Run(dir, 0, -1)
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	if nread > 3 {
+		t.Fatalf("too many events read after failure: got=%d, want<=3", nread)
+	}
+}
+
+// perfect labels the hits of the events generated by testevent.New, with 5
+// hits per particle, with their particle.
+type perfect struct {
+	cur, max *int32
+}
+
+func (p perfect) Predict(hits []trackml.Hit) ([]int, error) {
+	n := atomic.AddInt32(p.cur, 1)
+	defer atomic.AddInt32(p.cur, -1)
+	for {
+		max := atomic.LoadInt32(p.max)
+		if n <= max || atomic.CompareAndSwapInt32(p.max, max, n) {
+			break
+		}
+	}
+
+	labels := make([]int, len(hits))
+	for i, hit := range hits {
+		labels[i] = (hit.HitID - 1) / 5
+	}
+	return labels, nil
+}
+
+// cellCounter labels the hits of the events generated by testevent.New with
+// their particle, provided each hit has its 2 cells.
+type cellCounter struct{}
+
+func (cellCounter) Predict(hits []trackml.Hit) ([]int, error) {
+	return nil, fmt.Errorf("cells are needed")
+}
+
+func (cellCounter) PredictEvent(evt trackml.Event) ([]int, error) {
+	ncells := make(map[int]int, len(evt.Hits))
+	for _, cell := range evt.Cells {
+		ncells[cell.HitID]++
+	}
+	labels := make([]int, len(evt.Hits))
+	for i, hit := range evt.Hits {
+		if ncells[hit.HitID] != 2 {
+			return nil, fmt.Errorf("hit %d has %d cells", hit.HitID, ncells[hit.HitID])
+		}
+		labels[i] = (hit.HitID - 1) / 5
+	}
+	return labels, nil
+}
@@ -0,0 +1,356 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package trackml
+
+import (
+	"bytes"
+	"io/fs"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// The readers below decode the numeric CSV files of an event without any
+// per-row allocation.
+// As with ReadCSV, columns are located by name from the header line and
+// extra columns are ignored.
+
+func readHits(fsys fs.FS, fname string) ([]Hit, error) {
+	var (
+		hits []Hit
+		hit  Hit
+	)
+	err := scanCSV(fsys, fname, hitLayout.fields(&hit),
+		func(n int) { hits = make([]Hit, 0, n) },
+		func() { hits = append(hits, hit) },
+	)
+	return hits, err
+}
+
+func readCells(fsys fs.FS, fname string) ([]Cell, error) {
+	var (
+		cells []Cell
+		cell  Cell
+	)
+	err := scanCSV(fsys, fname, cellLayout.fields(&cell),
+		func(n int) { cells = make([]Cell, 0, n) },
+		func() { cells = append(cells, cell) },
+	)
+	return cells, err
+}
+
+func readParticles(fsys fs.FS, fname string) ([]Particle, error) {
+	var (
+		ps []Particle
+		p  Particle
+	)
+	err := scanCSV(fsys, fname, particleLayout.fields(&p),
+		func(n int) { ps = make([]Particle, 0, n) },
+		func() { ps = append(ps, p) },
+	)
+	return ps, err
+}
+
+func readMcTruth(fsys fs.FS, fname string) ([]Truth, error) {
+	var (
+		mcs []Truth
+		mc  Truth
+	)
+	err := scanCSV(fsys, fname, truthLayout.fields(&mc),
+		func(n int) { mcs = make([]Truth, 0, n) },
+		func() { mcs = append(mcs, mc) },
+	)
+	return mcs, err
+}
+
+// The layouts of the event files are derived once from the csv tags of
+// their structs.
+var (
+	hitLayout      = newLayout(reflect.TypeOf(Hit{}))
+	cellLayout     = newLayout(reflect.TypeOf(Cell{}))
+	particleLayout = newLayout(reflect.TypeOf(Particle{}))
+	truthLayout    = newLayout(reflect.TypeOf(Truth{}))
+)
+
+// layout holds the indices of the csv tagged fields of a struct type, by
+// column name.
+type layout map[string]int
+
+// newLayout returns the layout of the provided struct type, whose csv tagged
+// fields must be of type int or float64.
+func newLayout(typ reflect.Type) layout {
+	l := make(layout)
+	for i := 0; i < typ.NumField(); i++ {
+		ft := typ.Field(i)
+		name := ft.Tag.Get("csv")
+		if name == "" {
+			continue
+		}
+		if ft.Type != reflect.TypeOf(int(0)) && ft.Type != reflect.TypeOf(float64(0)) {
+			panic(errors.Errorf("trackml: unsupported type %v for column %q", ft.Type, name))
+		}
+		l[name] = i
+	}
+	return l
+}
+
+// fields returns the fields of the struct pointed to by ptr, by column name.
+func (l layout) fields(ptr interface{}) map[string]field {
+	var (
+		v    = reflect.ValueOf(ptr).Elem()
+		cols = make(map[string]field, len(l))
+	)
+	for name, i := range l {
+		switch p := v.Field(i).Addr().Interface().(type) {
+		case *int:
+			cols[name] = field{i: p}
+		case *float64:
+			cols[name] = field{f: p}
+		}
+	}
+	return cols
+}
+
+// field is the destination of a CSV column, an int or a float64.
+type field struct {
+	i *int
+	f *float64
+}
+
+// scanCSV decodes each row of the named CSV file of fsys into the fields of
+// the provided columns.
+// reserve is called once with an upper bound of the number of rows, before
+// the first call to emit, which is called after each decoded row.
+func scanCSV(fsys fs.FS, fname string, cols map[string]field, reserve func(n int), emit func()) error {
+	data, err := readFile(fsys, fname)
+	if err != nil {
+		return errors.Wrapf(err, "could not read CSV file")
+	}
+	if len(data) == 0 {
+		return errors.Errorf("%s: missing header line", fname)
+	}
+
+	hdr, data := nextLine(data)
+
+	// fields holds the destination of each column of the file, if any.
+	var (
+		fields []field
+		names  []string
+		found  = 0
+	)
+	for _, col := range bytes.Split(hdr, []byte(",")) {
+		name := string(bytes.TrimSpace(col))
+		fld, ok := cols[name]
+		if ok {
+			found++
+		}
+		fields = append(fields, fld)
+		names = append(names, name)
+	}
+	if found != len(cols) {
+		for _, name := range csvOrder(cols) {
+			if !contains(names, name) {
+				return errors.Errorf("%s: missing column %q", fname, name)
+			}
+		}
+	}
+
+	if len(data) > 0 {
+		reserve(bytes.Count(data, []byte("\n")) + 1)
+	}
+
+	for line := 2; len(data) > 0; line++ {
+		var row []byte
+		row, data = nextLine(data)
+		if len(row) == 0 {
+			continue
+		}
+
+		n, beg := 0, 0
+		for i := 0; i <= len(row); i++ {
+			if i < len(row) && row[i] != ',' {
+				continue
+			}
+			if n < len(fields) {
+				err = fields[n].decode(row[beg:i])
+				if err != nil {
+					return errors.Errorf(
+						"%s:%d: invalid value %q for column %q: %v",
+						fname, line, row[beg:i], names[n], err,
+					)
+				}
+			}
+			n++
+			beg = i + 1
+		}
+		if n != len(fields) {
+			return errors.Errorf(
+				"%s:%d: wrong number of fields (%d), want %d",
+				fname, line, n, len(fields),
+			)
+		}
+		emit()
+	}
+	return nil
+}
+
+// nextLine splits data after its first line, and returns that line without
+// its end-of-line marker.
+func nextLine(data []byte) (line, rest []byte) {
+	i := bytes.IndexByte(data, '\n')
+	if i < 0 {
+		line, rest = data, nil
+	} else {
+		line, rest = data[:i], data[i+1:]
+	}
+	return bytes.TrimSuffix(line, []byte("\r")), rest
+}
+
+func (f field) decode(b []byte) error {
+	switch {
+	case f.i != nil:
+		v, err := parseInt(b)
+		if err != nil {
+			return err
+		}
+		*f.i = v
+	case f.f != nil:
+		v, err := parseFloat(b)
+		if err != nil {
+			return err
+		}
+		*f.f = v
+	}
+	return nil
+}
+
+// parseInt parses a decimal integer.
+func parseInt(b []byte) (int, error) {
+	i, neg := 0, false
+	if len(b) > 0 && (b[0] == '-' || b[0] == '+') {
+		neg = b[0] == '-'
+		i++
+	}
+	if i == len(b) || len(b)-i > 18 {
+		// empty, or possibly overflowing: let strconv handle it.
+		v, err := strconv.ParseInt(string(b), 10, 0)
+		return int(v), err
+	}
+	v := 0
+	for ; i < len(b); i++ {
+		c := b[i]
+		if c < '0' || c > '9' {
+			return 0, strconv.ErrSyntax
+		}
+		v = v*10 + int(c-'0')
+	}
+	if neg {
+		v = -v
+	}
+	return v, nil
+}
+
+// pow10 holds the powers of 10 exactly representable as a float64.
+var pow10 = [...]float64{
+	1e0, 1e1, 1e2, 1e3, 1e4, 1e5, 1e6, 1e7, 1e8, 1e9, 1e10,
+	1e11, 1e12, 1e13, 1e14, 1e15, 1e16, 1e17, 1e18, 1e19, 1e20,
+	1e21, 1e22,
+}
+
+// parseFloat parses a decimal floating-point number.
+//
+// Numbers whose digits fit in 53 bits, with a decimal exponent of at most 22
+// in magnitude, are converted exactly with a single multiplication or
+// division.
+// Others are handed to strconv.ParseFloat.
+func parseFloat(b []byte) (float64, error) {
+	if v, ok := fastFloat(b); ok {
+		return v, nil
+	}
+	return strconv.ParseFloat(string(b), 64)
+}
+
+func fastFloat(b []byte) (float64, bool) {
+	const maxMant = 1 << 53
+
+	var (
+		i      = 0
+		neg    = false
+		mant   uint64
+		exp    = 0
+		digits = false
+		dot    = false
+	)
+	if len(b) > 0 && (b[0] == '-' || b[0] == '+') {
+		neg = b[0] == '-'
+		i++
+	}
+
+loop:
+	for ; i < len(b); i++ {
+		c := b[i]
+		switch {
+		case '0' <= c && c <= '9':
+			digits = true
+			mant = mant*10 + uint64(c-'0')
+			if mant > maxMant {
+				return 0, false
+			}
+			if dot {
+				exp--
+			}
+		case c == '.' && !dot:
+			dot = true
+		case (c == 'e' || c == 'E') && digits:
+			e, err := parseInt(b[i+1:])
+			if err != nil || e < -400 || e > 400 {
+				return 0, false
+			}
+			exp += e
+			break loop
+		default:
+			return 0, false
+		}
+	}
+	if !digits {
+		return 0, false
+	}
+
+	v := float64(mant)
+	switch {
+	case exp == 0:
+	case 0 < exp && exp < len(pow10):
+		v *= pow10[exp]
+	case -len(pow10) < exp && exp < 0:
+		v /= pow10[-exp]
+	default:
+		return 0, false
+	}
+	if neg {
+		v = -v
+	}
+	return v, true
+}
+
+// csvOrder returns the names of the provided columns, sorted.
+func csvOrder(cols map[string]field) []string {
+	names := make([]string, 0, len(cols))
+	for name := range cols {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func contains(vs []string, v string) bool {
+	for _, s := range vs {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,197 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package trackml
+
+import (
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/sbinet/go-trackml/internal/testevent"
+)
+
+func TestParseFloat(t *testing.T) {
+	vs := []string{
+		"0", "-0", "1", "+1", "-1", "0.5", ".5", "5.", "455.2038441487739",
+		"-1.4142135623730951", "1e10", "1E-5", "2.5e+3", "-7.25e-12",
+		"9007199254740993", "1e23", "1e-400", "1e400", "0.000000000000000000001",
+		"inf", "-Inf", "NaN",
+		"", "-", ".", "e5", "1e", "1.2.3", "1,5", "0x10", " 1",
+	}
+	rnd := rand.New(rand.NewSource(1234))
+	for i := 0; i < 1000; i++ {
+		v := rnd.NormFloat64() * math.Pow(10, float64(rnd.Intn(20)-10))
+		vs = append(vs,
+			strconv.FormatFloat(v, 'g', -1, 64),
+			strconv.FormatFloat(v, 'f', 6, 64),
+			strconv.FormatFloat(v, 'e', 3, 64),
+			strconv.FormatFloat(v, 'g', -1, 32),
+		)
+	}
+
+	for _, s := range vs {
+		got, err := parseFloat([]byte(s))
+		want, werr := strconv.ParseFloat(s, 64)
+		if (err == nil) != (werr == nil) {
+			t.Fatalf("%q: got err=%v, want err=%v", s, err, werr)
+		}
+		if err != nil {
+			continue
+		}
+		if math.Float64bits(got) != math.Float64bits(want) && !(math.IsNaN(got) && math.IsNaN(want)) {
+			t.Fatalf("%q: got=%v, want=%v", s, got, want)
+		}
+	}
+}
+
+func TestParseInt(t *testing.T) {
+	for _, s := range []string{
+		"0", "-0", "1", "+1", "-42", "123456789012345678", "-9223372036854775808",
+		"9223372036854775808", "", "-", "1.0", "1e3", "0x1", " 1",
+	} {
+		got, err := parseInt([]byte(s))
+		want, werr := strconv.ParseInt(s, 10, 0)
+		if (err == nil) != (werr == nil) {
+			t.Fatalf("%q: got err=%v, want err=%v", s, err, werr)
+		}
+		if err == nil && int64(got) != want {
+			t.Fatalf("%q: got=%v, want=%v", s, got, want)
+		}
+	}
+}
+
+func TestLayouts(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		cols map[string]field
+	}{
+		{"hits", hitLayout.fields(new(Hit))},
+		{"cells", cellLayout.fields(new(Cell))},
+		{"particles", particleLayout.fields(new(Particle))},
+		{"truth", truthLayout.fields(new(Truth))},
+	} {
+		got := csvOrder(tc.cols)
+		want := append([]string(nil), Schemas[tc.name]...)
+		sort.Strings(want)
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("%s: invalid columns:\ngot = %v\nwant= %v", tc.name, got, want)
+		}
+	}
+
+	var c Cell
+	cols := cellLayout.fields(&c)
+	*cols["hit_id"].i = 1
+	*cols["value"].f = 0.5
+	if c != (Cell{HitID: 1, Value: 0.5}) {
+		t.Fatalf("invalid cell: %+v", c)
+	}
+}
+
+func TestScanCSV(t *testing.T) {
+	testCSVDecoder(t, func(fname string) ([]Cell, error) {
+		return readCells(osFS{}, fname)
+	})
+}
+
+func TestReadEventFast(t *testing.T) {
+	dir, err := ioutil.TempDir("", "trkml-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fname, err := testevent.New(1000, 10).Write(dir, "event000000001")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		hits  []Hit
+		cells []Cell
+		ps    []Particle
+		mcs   []Truth
+	)
+	for _, v := range []struct {
+		name string
+		ptr  interface{}
+		fast func() (interface{}, error)
+	}{
+		{"hits", &hits, func() (interface{}, error) { return readHits(osFS{}, fname+"-hits.csv") }},
+		{"cells", &cells, func() (interface{}, error) { return readCells(osFS{}, fname+"-cells.csv") }},
+		{"particles", &ps, func() (interface{}, error) { return readParticles(osFS{}, fname+"-particles.csv") }},
+		{"truth", &mcs, func() (interface{}, error) { return readMcTruth(osFS{}, fname+"-truth.csv") }},
+	} {
+		err := ReadCSV(fname+"-"+v.name+".csv", v.ptr)
+		if err != nil {
+			t.Fatalf("%s: %v", v.name, err)
+		}
+		got, err := v.fast()
+		if err != nil {
+			t.Fatalf("%s: %v", v.name, err)
+		}
+		want := reflect.ValueOf(v.ptr).Elem().Interface()
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("%s: fast and generic readers disagree", v.name)
+		}
+	}
+}
+
+func BenchmarkReadEvent(b *testing.B) {
+	dir, err := ioutil.TempDir("", "trkml-")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fname, err := testevent.New(100000, 10).Write(dir, "event000000001")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.Run("fast", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var evt Event
+			evt.Hits, err = readHits(osFS{}, fname+"-hits.csv")
+			if err == nil {
+				evt.Cells, err = readCells(osFS{}, fname+"-cells.csv")
+			}
+			if err == nil {
+				evt.Ps, err = readParticles(osFS{}, fname+"-particles.csv")
+			}
+			if err == nil {
+				evt.Mcs, err = readMcTruth(osFS{}, fname+"-truth.csv")
+			}
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("generic", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var evt Event
+			err = ReadCSV(fname+"-hits.csv", &evt.Hits)
+			if err == nil {
+				err = ReadCSV(fname+"-cells.csv", &evt.Cells)
+			}
+			if err == nil {
+				err = ReadCSV(fname+"-particles.csv", &evt.Ps)
+			}
+			if err == nil {
+				err = ReadCSV(fname+"-truth.csv", &evt.Mcs)
+			}
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,126 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package features
+
+import (
+	"math"
+
+	"github.com/sbinet/go-trackml"
+)
+
+func init() {
+	Register("x", "global x position of the hit (mm)", func(ctx *Context, i int) float64 {
+		return ctx.Event.Hits[i].X
+	})
+	Register("y", "global y position of the hit (mm)", func(ctx *Context, i int) float64 {
+		return ctx.Event.Hits[i].Y
+	})
+	Register("z", "global z position of the hit (mm)", func(ctx *Context, i int) float64 {
+		return ctx.Event.Hits[i].Z
+	})
+	Register("r", "transverse distance of the hit to the beam axis (mm)", func(ctx *Context, i int) float64 {
+		hit := ctx.Event.Hits[i]
+		return math.Hypot(hit.X, hit.Y)
+	})
+	Register("phi", "azimuthal angle of the hit (rad)", func(ctx *Context, i int) float64 {
+		hit := ctx.Event.Hits[i]
+		return math.Atan2(hit.Y, hit.X)
+	})
+	Register("eta", "pseudo-rapidity of the hit, seen from the origin", func(ctx *Context, i int) float64 {
+		hit := ctx.Event.Hits[i]
+		return math.Asinh(hit.Z / math.Hypot(hit.X, hit.Y))
+	})
+	Register("z_over_r", "ratio of the z position to the transverse distance of the hit", func(ctx *Context, i int) float64 {
+		hit := ctx.Event.Hits[i]
+		return hit.Z / math.Hypot(hit.X, hit.Y)
+	})
+
+	Register("ncells", "number of cells of the hit", func(ctx *Context, i int) float64 {
+		return float64(len(ctx.Cells(i)))
+	})
+	Register("charge", "total charge deposited in the cells of the hit", func(ctx *Context, i int) float64 {
+		q := 0.0
+		for _, cell := range ctx.Cells(i) {
+			q += cell.Value
+		}
+		return q
+	})
+	Register("cluster_nu", "number of channels spanned by the cells of the hit along u", func(ctx *Context, i int) float64 {
+		nu, _ := Extent(ctx.Cells(i))
+		return float64(nu)
+	})
+	Register("cluster_nv", "number of channels spanned by the cells of the hit along v", func(ctx *Context, i int) float64 {
+		_, nv := Extent(ctx.Cells(i))
+		return float64(nv)
+	})
+	Register("cluster_du", "length spanned by the cells of the hit along u (mm)", func(ctx *Context, i int) float64 {
+		m, ok := ctx.Module(i)
+		if !ok {
+			return math.NaN()
+		}
+		nu, _ := Extent(ctx.Cells(i))
+		return float64(nu) * m.PitchU
+	})
+	Register("cluster_dv", "length spanned by the cells of the hit along v (mm)", func(ctx *Context, i int) float64 {
+		m, ok := ctx.Module(i)
+		if !ok {
+			return math.NaN()
+		}
+		_, nv := Extent(ctx.Cells(i))
+		return float64(nv) * m.PitchV
+	})
+
+	for j, name := range []string{"module_wx", "module_wy", "module_wz"} {
+		j := j
+		Register(name, "component of the normal to the module of the hit", func(ctx *Context, i int) float64 {
+			m, ok := ctx.Module(i)
+			if !ok {
+				return math.NaN()
+			}
+			return m.W()[j]
+		})
+	}
+	Register("cos_incidence", "cosine of the angle between the module normal and the direction of the hit from the origin", func(ctx *Context, i int) float64 {
+		m, ok := ctx.Module(i)
+		if !ok {
+			return math.NaN()
+		}
+		var (
+			hit = ctx.Event.Hits[i]
+			d   = math.Sqrt(hit.X*hit.X + hit.Y*hit.Y + hit.Z*hit.Z)
+			w   = m.W()
+		)
+		if d == 0 {
+			return math.NaN()
+		}
+		return math.Abs(w[0]*hit.X+w[1]*hit.Y+w[2]*hit.Z) / d
+	})
+}
+
+// Extent returns the number of channels spanned by the cells along u and v.
+func Extent(cells []trackml.Cell) (nu, nv int) {
+	if len(cells) == 0 {
+		return 0, 0
+	}
+	var (
+		umin, umax = cells[0].Ch0, cells[0].Ch0
+		vmin, vmax = cells[0].Ch1, cells[0].Ch1
+	)
+	for _, cell := range cells[1:] {
+		if cell.Ch0 < umin {
+			umin = cell.Ch0
+		}
+		if cell.Ch0 > umax {
+			umax = cell.Ch0
+		}
+		if cell.Ch1 < vmin {
+			vmin = cell.Ch1
+		}
+		if cell.Ch1 > vmax {
+			vmax = cell.Ch1
+		}
+	}
+	return umax - umin + 1, vmax - vmin + 1
+}
@@ -0,0 +1,142 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package features extracts per-hit features, suitable for training machine
+// learning models.
+//
+// Features are registered under a name, and an Extractor computes a list
+// of named features for all the hits of an event.
+package features // import "github.com/sbinet/go-trackml/features"
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/sbinet/go-trackml"
+	"gonum.org/v1/gonum/mat"
+)
+
+// Func computes a feature of the i-th hit of the event held by ctx.
+type Func func(ctx *Context, i int) float64
+
+// Feature is a named per-hit feature.
+type Feature struct {
+	Name string
+	Doc  string
+	Func Func
+}
+
+var registry = make(map[string]Feature)
+
+// Register registers a feature under the provided name.
+// Register panics if a feature with the same name was already registered.
+func Register(name, doc string, fct Func) {
+	if _, dup := registry[name]; dup {
+		panic(errors.Errorf("features: feature %q already registered", name))
+	}
+	registry[name] = Feature{Name: name, Doc: doc, Func: fct}
+}
+
+// Lookup returns the feature registered under the provided name.
+func Lookup(name string) (Feature, bool) {
+	f, ok := registry[name]
+	return f, ok
+}
+
+// Names returns the sorted names of all the registered features.
+func Names() []string {
+	names := make([]string, 0, len(registry))
+	for k := range registry {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Context gives features access to the event being processed.
+type Context struct {
+	Event    trackml.Event
+	Detector *trackml.Detector // detector geometry, may be nil
+
+	cells map[int][]trackml.Cell
+}
+
+// NewContext returns a context for the provided event and detector.
+// The detector may be nil, in which case features depending on the detector
+// geometry evaluate to NaN.
+func NewContext(evt trackml.Event, det *trackml.Detector) *Context {
+	ctx := &Context{
+		Event:    evt,
+		Detector: det,
+		cells:    make(map[int][]trackml.Cell, len(evt.Hits)),
+	}
+	for _, cell := range evt.Cells {
+		ctx.cells[cell.HitID] = append(ctx.cells[cell.HitID], cell)
+	}
+	return ctx
+}
+
+// Cells returns the cells of the i-th hit.
+func (ctx *Context) Cells(i int) []trackml.Cell {
+	return ctx.cells[ctx.Event.Hits[i].HitID]
+}
+
+// Module returns the module of the i-th hit.
+func (ctx *Context) Module(i int) (trackml.Module, bool) {
+	if ctx.Detector == nil {
+		return trackml.Module{}, false
+	}
+	return ctx.Detector.HitModule(ctx.Event.Hits[i])
+}
+
+// Extractor computes a list of features for all the hits of events.
+type Extractor struct {
+	Detector *trackml.Detector // detector geometry, may be nil
+
+	features []Feature
+}
+
+// New returns an extractor computing the named features, in order.
+func New(det *trackml.Detector, names ...string) (*Extractor, error) {
+	ex := &Extractor{
+		Detector: det,
+		features: make([]Feature, len(names)),
+	}
+	for i, name := range names {
+		f, ok := Lookup(name)
+		if !ok {
+			return nil, errors.Errorf("features: unknown feature %q", name)
+		}
+		ex.features[i] = f
+	}
+	return ex, nil
+}
+
+// Names returns the names of the features computed by the extractor.
+func (ex *Extractor) Names() []string {
+	names := make([]string, len(ex.features))
+	for i, f := range ex.features {
+		names[i] = f.Name
+	}
+	return names
+}
+
+// Extract returns the features of the hits of the provided event, with one
+// row per hit and one column per feature.
+// Extract returns nil if the event has no hits or no feature is requested.
+func (ex *Extractor) Extract(evt trackml.Event) *mat.Dense {
+	if len(evt.Hits) == 0 || len(ex.features) == 0 {
+		return nil
+	}
+	var (
+		ctx = NewContext(evt, ex.Detector)
+		m   = mat.NewDense(len(evt.Hits), len(ex.features), nil)
+	)
+	for i := range evt.Hits {
+		for j, f := range ex.features {
+			m.Set(i, j, f.Func(ctx, i))
+		}
+	}
+	return m
+}
@@ -0,0 +1,133 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package features
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/sbinet/go-trackml"
+	"gonum.org/v1/gonum/mat"
+)
+
+func newEvent() (trackml.Event, *trackml.Detector) {
+	evt := trackml.Event{
+		Hits: []trackml.Hit{
+			{HitID: 1, X: 3, Y: 4, Z: 5, VolumeID: 8, LayerID: 2, ModuleID: 1},
+			{HitID: 2, X: 0, Y: -2, Z: 0, VolumeID: 8, LayerID: 4, ModuleID: 1},
+		},
+		Cells: []trackml.Cell{
+			{HitID: 1, Ch0: 10, Ch1: 20, Value: 0.25},
+			{HitID: 1, Ch0: 11, Ch1: 20, Value: 0.5},
+			{HitID: 1, Ch0: 12, Ch1: 22, Value: 0.25},
+			{HitID: 2, Ch0: 5, Ch1: 5, Value: 1},
+		},
+		Mcs: []trackml.Truth{
+			{HitID: 1, PID: 42},
+			{HitID: 2, PID: 0},
+		},
+	}
+	det := trackml.NewDetector([]trackml.Module{
+		{
+			VolumeID: 8, LayerID: 2, ModuleID: 1,
+			RotXW: 0.6, RotYW: 0.8,
+			PitchU: 0.05, PitchV: 0.5,
+		},
+	})
+	return evt, det
+}
+
+func TestExtract(t *testing.T) {
+	evt, det := newEvent()
+	names := []string{
+		"r", "phi", "z_over_r", "ncells", "charge",
+		"cluster_nu", "cluster_nv", "cluster_du", "cluster_dv",
+		"module_wx", "cos_incidence",
+	}
+	ex, err := New(det, names...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ex.Names(); !reflect.DeepEqual(got, names) {
+		t.Fatalf("invalid names\ngot = %v\nwant= %v", got, names)
+	}
+
+	m := ex.Extract(evt)
+	nan := math.NaN()
+	want := mat.NewDense(2, len(names), []float64{
+		5, math.Atan2(4, 3), 1, 3, 1, 3, 3, 0.15, 1.5, 0.6, 5 / math.Sqrt(50),
+		2, -math.Pi / 2, 0, 1, 1, 1, 1, nan, nan, nan, nan,
+	})
+	for i := 0; i < 2; i++ {
+		for j, name := range names {
+			got, want := m.At(i, j), want.At(i, j)
+			if math.IsNaN(want) && math.IsNaN(got) {
+				continue
+			}
+			if math.Abs(got-want) > 1e-12 {
+				t.Fatalf("hit %d: invalid %s: got=%v, want=%v", i, name, got, want)
+			}
+		}
+	}
+}
+
+func TestUnknownFeature(t *testing.T) {
+	_, err := New(nil, "r", "not-there")
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	for _, name := range Names() {
+		f, ok := Lookup(name)
+		if !ok || f.Name != name || f.Doc == "" || f.Func == nil {
+			t.Fatalf("invalid feature %q: %+v", name, f)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected a panic")
+		}
+	}()
+	Register("r", "duplicate", nil)
+}
+
+func TestWriteCSV(t *testing.T) {
+	evt, _ := newEvent()
+	ex, err := New(nil, "z", "ncells")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := new(bytes.Buffer)
+	err = WriteCSV(buf, evt, ex.Names(), ex.Extract(evt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "hit_id,z,ncells,particle_id\n1,5,3,42\n2,0,1,0\n"
+	if got := buf.String(); got != want {
+		t.Fatalf("invalid CSV\ngot = %q\nwant= %q", got, want)
+	}
+
+	// truth is matched to hits by hit ID.
+	buf.Reset()
+	evt.Mcs = []trackml.Truth{{HitID: 1, PID: 42}}
+	err = WriteCSV(buf, evt, ex.Names(), ex.Extract(evt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != want {
+		t.Fatalf("invalid CSV\ngot = %q\nwant= %q", got, want)
+	}
+
+	err = WriteCSV(buf, evt, []string{"z"}, ex.Extract(evt))
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package features
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sbinet/go-trackml"
+	"gonum.org/v1/gonum/mat"
+)
+
+// WriteCSV writes the features m of the hits of the provided event to w, in
+// CSV format with a header line.
+//
+// The columns are hit_id, the named features and, if the event holds
+// Monte-Carlo truth, particle_id, matched to the hits by hit ID.
+func WriteCSV(w io.Writer, evt trackml.Event, names []string, m *mat.Dense) error {
+	if len(evt.Hits) > 0 && len(names) > 0 {
+		var r, c int
+		if m != nil {
+			r, c = m.Dims()
+		}
+		if r != len(evt.Hits) || c != len(names) {
+			return errors.Errorf(
+				"features: invalid matrix dimensions (%d,%d), want (%d,%d)",
+				r, c, len(evt.Hits), len(names),
+			)
+		}
+	}
+	var pids []int
+	if len(evt.Mcs) > 0 {
+		pids = evt.ParticleIDs()
+	}
+
+	bw := bufio.NewWriter(w)
+	bw.WriteString("hit_id")
+	for _, name := range names {
+		bw.WriteString("," + name)
+	}
+	if pids != nil {
+		bw.WriteString(",particle_id")
+	}
+	bw.WriteString("\n")
+
+	row := make([]string, 0, len(names)+2)
+	for i, hit := range evt.Hits {
+		row = append(row[:0], strconv.Itoa(hit.HitID))
+		for j := range names {
+			row = append(row, strconv.FormatFloat(m.At(i, j), 'g', -1, 64))
+		}
+		if pids != nil {
+			row = append(row, strconv.Itoa(pids[i]))
+		}
+		bw.WriteString(strings.Join(row, ","))
+		bw.WriteString("\n")
+	}
+
+	err := bw.Flush()
+	if err != nil {
+		return errors.Wrapf(err, "features: could not write CSV data")
+	}
+	return nil
+}
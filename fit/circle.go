@@ -0,0 +1,244 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fit
+
+import (
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Circle is a circle in the transverse plane.
+type Circle struct {
+	X0, Y0 float64 // center of the circle (mm)
+	R      float64 // radius of the circle (mm)
+}
+
+// Dist returns the signed distance of the point (x,y) to the circle.
+// The distance is positive outside of the circle.
+func (c Circle) Dist(x, y float64) float64 {
+	return math.Hypot(x-c.X0, y-c.Y0) - c.R
+}
+
+// CircleFitter fits a circle through a set of points.
+type CircleFitter func(xs, ys []float64) (Circle, error)
+
+// Kasa fits a circle through the provided points with the algebraic method
+// of Kasa, minimizing:
+//
+//	sum_i (x_i^2 + y_i^2 + D*x_i + E*y_i + F)^2
+//
+// The Kasa fit is fast but biased towards smaller circles for points spanning
+// a small arc.
+func Kasa(xs, ys []float64) (Circle, error) {
+	if err := checkPoints(xs, ys, 3); err != nil {
+		return Circle{}, err
+	}
+	n := len(xs)
+	mx, my := centroid(xs, ys)
+
+	a := mat.NewDense(n, 3, nil)
+	b := mat.NewVecDense(n, nil)
+	for i := range xs {
+		x := xs[i] - mx
+		y := ys[i] - my
+		a.Set(i, 0, x)
+		a.Set(i, 1, y)
+		a.Set(i, 2, 1)
+		b.SetVec(i, -(x*x + y*y))
+	}
+
+	var sol mat.VecDense
+	if err := sol.SolveVec(a, b); err != nil {
+		return Circle{}, errDegenerate
+	}
+
+	var (
+		cx = -0.5 * sol.AtVec(0)
+		cy = -0.5 * sol.AtVec(1)
+		r2 = cx*cx + cy*cy - sol.AtVec(2)
+	)
+	return newCircle(cx+mx, cy+my, r2)
+}
+
+// Taubin fits a circle through the provided points with the algebraic method
+// of Taubin, as implemented by N. Chernov with a Newton solver.
+//
+// The Taubin fit is nearly as precise as a geometric fit, and much faster.
+func Taubin(xs, ys []float64) (Circle, error) {
+	if err := checkPoints(xs, ys, 3); err != nil {
+		return Circle{}, err
+	}
+	n := float64(len(xs))
+	mx, my := centroid(xs, ys)
+
+	var mxx, myy, mxy, mxz, myz, mzz float64
+	for i := range xs {
+		x := xs[i] - mx
+		y := ys[i] - my
+		z := x*x + y*y
+		mxy += x * y
+		mxx += x * x
+		myy += y * y
+		mxz += x * z
+		myz += y * z
+		mzz += z * z
+	}
+	mxx /= n
+	myy /= n
+	mxy /= n
+	mxz /= n
+	myz /= n
+	mzz /= n
+
+	var (
+		mz    = mxx + myy
+		covXY = mxx*myy - mxy*mxy
+		varZ  = mzz - mz*mz
+		a3    = 4 * mz
+		a2    = -3*mz*mz - mzz
+		a1    = varZ*mz + 4*covXY*mz - mxz*mxz - myz*myz
+		a0    = mxz*(mxz*myy-myz*mxy) + myz*(myz*mxx-mxz*mxy) - varZ*covXY
+		a22   = a2 + a2
+		a33   = a3 + a3 + a3
+	)
+
+	// Newton's method on the characteristic polynomial, starting at x=0.
+	x, y := 0.0, a0
+	for iter := 0; iter < 99; iter++ {
+		dy := a1 + x*(a22+a33*x)
+		xnew := x - y/dy
+		if xnew == x || math.IsNaN(xnew) || math.IsInf(xnew, 0) {
+			break
+		}
+		ynew := a0 + xnew*(a1+xnew*(a2+xnew*a3))
+		if math.Abs(ynew) >= math.Abs(y) {
+			break
+		}
+		x, y = xnew, ynew
+	}
+
+	det := x*x - x*mz + covXY
+	if det == 0 {
+		return Circle{}, errDegenerate
+	}
+	var (
+		cx = (mxz*(myy-x) - myz*mxy) / det / 2
+		cy = (myz*(mxx-x) - mxz*mxy) / det / 2
+	)
+	return newCircle(cx+mx, cy+my, cx*cx+cy*cy+mz)
+}
+
+// Riemann fits a circle through the provided points by mapping them onto
+// the paraboloid z = x^2 + y^2 and fitting a plane to the mapped points.
+// The intersection of that plane with the paraboloid projects back onto the
+// fitted circle.
+func Riemann(xs, ys []float64) (Circle, error) {
+	if err := checkPoints(xs, ys, 3); err != nil {
+		return Circle{}, err
+	}
+	n := float64(len(xs))
+	mx, my := centroid(xs, ys)
+
+	var (
+		ps  = make([][3]float64, len(xs))
+		ctr [3]float64
+	)
+	for i := range xs {
+		x := xs[i] - mx
+		y := ys[i] - my
+		ps[i] = [3]float64{x, y, x*x + y*y}
+		for j, v := range ps[i] {
+			ctr[j] += v / n
+		}
+	}
+
+	cov := mat.NewSymDense(3, nil)
+	for _, p := range ps {
+		for i := 0; i < 3; i++ {
+			for j := i; j < 3; j++ {
+				cov.SetSym(i, j, cov.At(i, j)+(p[i]-ctr[i])*(p[j]-ctr[j]))
+			}
+		}
+	}
+
+	var eig mat.EigenSym
+	if !eig.Factorize(cov, true) {
+		return Circle{}, errDegenerate
+	}
+	var vecs mat.Dense
+	eig.VectorsTo(&vecs)
+
+	// eigen values are in ascending order: the normal to the plane is
+	// the eigen vector associated with the smallest one.
+	var (
+		n0 = vecs.At(0, 0)
+		n1 = vecs.At(1, 0)
+		n2 = vecs.At(2, 0)
+		c  = -(n0*ctr[0] + n1*ctr[1] + n2*ctr[2])
+	)
+	if n2 == 0 {
+		return Circle{}, errDegenerate
+	}
+	var (
+		cx = -n0 / (2 * n2)
+		cy = -n1 / (2 * n2)
+		r2 = (n0*n0 + n1*n1 - 4*n2*c) / (4 * n2 * n2)
+	)
+	return newCircle(cx+mx, cy+my, r2)
+}
+
+// circleCov returns the covariance matrix of the (X0, Y0, R) parameters
+// of the circle c fitted through the provided points, with a resolution
+// sigma on the distance of each point to the circle.
+func circleCov(c Circle, xs, ys []float64, sigma float64) (*mat.SymDense, error) {
+	jac := mat.NewDense(len(xs), 3, nil)
+	for i := range xs {
+		dx := xs[i] - c.X0
+		dy := ys[i] - c.Y0
+		rho := math.Hypot(dx, dy)
+		jac.Set(i, 0, -dx/rho)
+		jac.Set(i, 1, -dy/rho)
+		jac.Set(i, 2, -1)
+	}
+	var jtj mat.SymDense
+	jtj.SymOuterK(1/(sigma*sigma), jac.T())
+
+	var chol mat.Cholesky
+	if !chol.Factorize(&jtj) {
+		return nil, errDegenerate
+	}
+	cov := mat.NewSymDense(3, nil)
+	if err := chol.InverseTo(cov); err != nil {
+		return nil, errDegenerate
+	}
+	return cov, nil
+}
+
+func newCircle(x0, y0, r2 float64) (Circle, error) {
+	if !(r2 > 0) || math.IsInf(r2, 0) {
+		return Circle{}, errDegenerate
+	}
+	return Circle{X0: x0, Y0: y0, R: math.Sqrt(r2)}, nil
+}
+
+func checkPoints(xs, ys []float64, min int) error {
+	if len(xs) != len(ys) {
+		return errLenMismatch
+	}
+	if len(xs) < min {
+		return errTooFewPoints
+	}
+	return nil
+}
+
+func centroid(xs, ys []float64) (mx, my float64) {
+	for i := range xs {
+		mx += xs[i]
+		my += ys[i]
+	}
+	n := float64(len(xs))
+	return mx / n, my / n
+}
@@ -0,0 +1,261 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package fit provides track fitting facilities for reconstructed tracks.
+//
+// Tracks are modeled as helices with their axis along the z axis, the
+// direction of the solenoid magnetic field.
+package fit // import "github.com/sbinet/go-trackml/fit"
+
+import (
+	"math"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/sbinet/go-trackml"
+	"gonum.org/v1/gonum/mat"
+)
+
+var (
+	errLenMismatch  = errors.Errorf("fit: length mismatch")
+	errTooFewPoints = errors.Errorf("fit: not enough points")
+	errDegenerate   = errors.Errorf("fit: degenerate configuration")
+)
+
+// ptFactor converts a radius of curvature in mm times a magnetic field
+// in Tesla into a transverse momentum in GeV/c.
+const ptFactor = 0.299792458e-3
+
+// Helix describes a helical track by its perigee parameters, with respect to
+// the origin of the transverse plane.
+//
+// The point of closest approach to the z axis is located at:
+//
+//	(-D0*sin(Phi0), D0*cos(Phi0), Z0)
+type Helix struct {
+	D0       float64 // signed transverse impact parameter (mm)
+	Z0       float64 // longitudinal impact parameter (mm)
+	Phi0     float64 // azimuthal angle of the momentum at the point of closest approach (rad)
+	CotTheta float64 // cotangent of the polar angle
+	QOverPt  float64 // charge over transverse momentum (1/(GeV/c))
+
+	Circle Circle // circle fitted in the transverse plane
+	Line   Line   // line fitted in the s-z plane
+
+	Cov  *mat.SymDense // covariance matrix of (D0, Z0, Phi0, CotTheta, QOverPt)
+	Chi2 float64       // chi2 of the fit
+	NDF  int           // number of degrees of freedom of the fit
+}
+
+// Fitter fits helices through hits.
+type Fitter struct {
+	Circle  CircleFitter // circle fit method. Taubin if nil.
+	BField  float64      // magnetic field along z (Tesla)
+	SigmaXY float64      // hit resolution in the transverse plane (mm)
+	SigmaZ  float64      // hit resolution along z (mm)
+}
+
+// NewFitter returns a Fitter using the Taubin circle fit, in a 2 Tesla field.
+func NewFitter() *Fitter {
+	return &Fitter{
+		Circle:  Taubin,
+		BField:  2,
+		SigmaXY: 0.1,
+		SigmaZ:  0.5,
+	}
+}
+
+// Fit fits a helix through the provided hits.
+//
+// The circle parameters in the transverse plane and the line parameters in
+// the s-z plane are assumed to be uncorrelated.
+func (f *Fitter) Fit(hits []trackml.Hit) (Helix, error) {
+	var hlx Helix
+	if len(hits) < 3 {
+		return hlx, errTooFewPoints
+	}
+	fitCircle := f.Circle
+	if fitCircle == nil {
+		fitCircle = Taubin
+	}
+
+	hits = append([]trackml.Hit(nil), hits...)
+	sort.Slice(hits, func(i, j int) bool {
+		return hits[i].X*hits[i].X+hits[i].Y*hits[i].Y < hits[j].X*hits[j].X+hits[j].Y*hits[j].Y
+	})
+
+	var (
+		n  = len(hits)
+		xs = make([]float64, n)
+		ys = make([]float64, n)
+		zs = make([]float64, n)
+	)
+	for i, hit := range hits {
+		xs[i] = hit.X
+		ys[i] = hit.Y
+		zs[i] = hit.Z
+	}
+
+	c, err := fitCircle(xs, ys)
+	if err != nil {
+		return hlx, errors.Wrapf(err, "fit: could not fit circle")
+	}
+	hlx.Circle = c
+
+	orient := orientation(c, xs, ys)
+	p := perigee(c, orient, f.BField)
+	hlx.D0 = p[0]
+	hlx.Phi0 = p[1]
+	hlx.QOverPt = p[2]
+
+	// arc lengths, from the point of closest approach.
+	var (
+		ss   = make([]float64, n)
+		dc   = math.Hypot(c.X0, c.Y0)
+		a0   = math.Atan2(-c.Y0/dc, -c.X0/dc)
+		prev = a0
+	)
+	for i := range xs {
+		a := math.Atan2(ys[i]-c.Y0, xs[i]-c.X0)
+		for a-prev > math.Pi {
+			a -= 2 * math.Pi
+		}
+		for a-prev < -math.Pi {
+			a += 2 * math.Pi
+		}
+		prev = a
+		ss[i] = orient * c.R * (a - a0)
+	}
+
+	line, err := LineSZ(ss, zs, f.SigmaZ)
+	if err != nil {
+		return hlx, errors.Wrapf(err, "fit: could not fit s-z line")
+	}
+	hlx.Line = line
+	hlx.Z0 = line.Z0
+	hlx.CotTheta = line.Slope
+
+	for i := range xs {
+		d := c.Dist(xs[i], ys[i]) / f.SigmaXY
+		hlx.Chi2 += d * d
+	}
+	hlx.Chi2 += line.Chi2
+	hlx.NDF = 2*n - 5
+
+	ccov, err := circleCov(c, xs, ys, f.SigmaXY)
+	if err != nil {
+		return hlx, errors.Wrapf(err, "fit: could not compute circle covariance")
+	}
+	jac := perigeeJacobian(c, orient, f.BField)
+	var pcov mat.Dense
+	pcov.Product(jac, ccov, jac.T())
+
+	// (D0, Z0, Phi0, CotTheta, QOverPt) <- (d0, phi0, q/pt) x (z0, cot)
+	var (
+		tr = [3]int{0, 2, 4}
+		sz = [2]int{1, 3}
+	)
+	hlx.Cov = mat.NewSymDense(5, nil)
+	for i, ii := range tr {
+		for j, jj := range tr {
+			if ii <= jj {
+				hlx.Cov.SetSym(ii, jj, pcov.At(i, j))
+			}
+		}
+	}
+	for i, ii := range sz {
+		for j, jj := range sz {
+			if ii <= jj {
+				hlx.Cov.SetSym(ii, jj, line.Cov.At(i, j))
+			}
+		}
+	}
+
+	return hlx, nil
+}
+
+// orientation returns +1 if the points are travelling counter-clockwise
+// around the center of the circle c, starting from the point of closest
+// approach of the circle to the origin, and -1 otherwise.
+//
+// The direction of travel is the one pointing from the point of closest
+// approach towards the centroid of the points, which is robust against
+// outliers for tracks spanning less than half a turn.
+func orientation(c Circle, xs, ys []float64) float64 {
+	var (
+		mx, my = centroid(xs, ys)
+		dc     = math.Hypot(c.X0, c.Y0)
+		ux     = -c.X0 / dc
+		uy     = -c.Y0 / dc
+		px     = c.X0 + c.R*ux
+		py     = c.Y0 + c.R*uy
+	)
+	// counter-clockwise tangent at the point of closest approach.
+	tx, ty := -uy, ux
+	if tx*(mx-px)+ty*(my-py) < 0 {
+		return -1
+	}
+	return +1
+}
+
+// perigee returns the (d0, phi0, q/pt) parameters of a track travelling along
+// the circle c with the provided orientation, in a magnetic field bfield.
+func perigee(c Circle, orient, bfield float64) [3]float64 {
+	var (
+		dc = math.Hypot(c.X0, c.Y0)
+		ux = -c.X0 / dc
+		uy = -c.Y0 / dc
+		px = c.X0 + c.R*ux
+		py = c.Y0 + c.R*uy
+
+		phi0 = math.Atan2(orient*ux, -orient*uy)
+		sin  = math.Sin(phi0)
+		cos  = math.Cos(phi0)
+		d0   = -px*sin + py*cos
+	)
+
+	// a positive particle travels clockwise in a magnetic field along +z.
+	q := -orient
+	if bfield < 0 {
+		q = -q
+	}
+	pt := ptFactor * math.Abs(bfield) * c.R
+	return [3]float64{d0, phi0, q / pt}
+}
+
+// perigeeJacobian returns the jacobian matrix of the (d0, phi0, q/pt)
+// perigee parameters with respect to the (X0, Y0, R) circle parameters.
+func perigeeJacobian(c Circle, orient, bfield float64) *mat.Dense {
+	jac := mat.NewDense(3, 3, nil)
+	ps := [3]float64{c.X0, c.Y0, c.R}
+	for j := range ps {
+		h := 1e-6 * math.Max(1, math.Abs(ps[j]))
+		hi := ps
+		lo := ps
+		hi[j] += h
+		lo[j] -= h
+		phi := perigee(Circle{X0: hi[0], Y0: hi[1], R: hi[2]}, orient, bfield)
+		plo := perigee(Circle{X0: lo[0], Y0: lo[1], R: lo[2]}, orient, bfield)
+		for i := range phi {
+			d := phi[i] - plo[i]
+			if i == 1 {
+				d = math.Remainder(d, 2*math.Pi)
+			}
+			jac.Set(i, j, d/(2*h))
+		}
+	}
+	return jac
+}
+
+// Select returns the hits labeled with the provided label, as returned by
+// a clustering.Classifier.
+func Select(hits []trackml.Hit, labels []int, label int) []trackml.Hit {
+	var o []trackml.Hit
+	for i, v := range labels {
+		if v == label {
+			o = append(o, hits[i])
+		}
+	}
+	return o
+}
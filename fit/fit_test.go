@@ -0,0 +1,198 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fit
+
+import (
+	"math"
+	"testing"
+
+	"github.com/sbinet/go-trackml"
+	"gonum.org/v1/gonum/floats/scalar"
+)
+
+// genHelix returns n hits along the helix with the provided perigee
+// parameters, in a magnetic field of bfield Tesla.
+func genHelix(n int, d0, z0, phi0, cot, qOverPt, bfield float64) []trackml.Hit {
+	var (
+		q  = math.Copysign(1, qOverPt)
+		r  = 1 / (math.Abs(qOverPt) * ptFactor * bfield)
+		px = -d0 * math.Sin(phi0)
+		py = +d0 * math.Cos(phi0)
+		// positive particles travel clockwise: the center is on the right.
+		cx = px + q*r*math.Sin(phi0)
+		cy = py - q*r*math.Cos(phi0)
+		b0 = math.Atan2(py-cy, px-cx)
+	)
+	hits := make([]trackml.Hit, n)
+	for i := range hits {
+		s := 30 * float64(i+1)
+		b := b0 - q*s/r
+		hits[i] = trackml.Hit{
+			HitID: i + 1,
+			X:     cx + r*math.Cos(b),
+			Y:     cy + r*math.Sin(b),
+			Z:     z0 + cot*s,
+		}
+	}
+	return hits
+}
+
+func TestCircle(t *testing.T) {
+	want := Circle{X0: 120, Y0: -350, R: 400}
+	var xs, ys []float64
+	for i := 0; i < 10; i++ {
+		a := 0.1 * float64(i)
+		xs = append(xs, want.X0+want.R*math.Cos(a))
+		ys = append(ys, want.Y0+want.R*math.Sin(a))
+	}
+
+	for _, tc := range []struct {
+		name string
+		fit  CircleFitter
+	}{
+		{"kasa", Kasa},
+		{"taubin", Taubin},
+		{"riemann", Riemann},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := tc.fit(xs, ys)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !scalar.EqualWithinAbs(got.X0, want.X0, 1e-6) ||
+				!scalar.EqualWithinAbs(got.Y0, want.Y0, 1e-6) ||
+				!scalar.EqualWithinAbs(got.R, want.R, 1e-6) {
+				t.Fatalf("invalid circle\ngot = %+v\nwant= %+v", got, want)
+			}
+		})
+	}
+
+	_, err := Taubin(xs[:2], ys[:2])
+	if err != errTooFewPoints {
+		t.Fatalf("invalid error: got=%v, want=%v", err, errTooFewPoints)
+	}
+}
+
+func TestLineSZ(t *testing.T) {
+	ss := []float64{0, 1, 2, 3}
+	zs := []float64{1, 3, 5, 7}
+	line, err := LineSZ(ss, zs, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !scalar.EqualWithinAbs(line.Z0, 1, 1e-12) || !scalar.EqualWithinAbs(line.Slope, 2, 1e-12) {
+		t.Fatalf("invalid line: z0=%v, slope=%v", line.Z0, line.Slope)
+	}
+	if line.NDF != 2 || line.Chi2 > 1e-12 {
+		t.Fatalf("invalid chi2/ndf: %v/%d", line.Chi2, line.NDF)
+	}
+	if got, want := line.Cov.At(1, 1), 0.2; !scalar.EqualWithinAbs(got, want, 1e-12) {
+		t.Fatalf("invalid slope variance: got=%v, want=%v", got, want)
+	}
+}
+
+func TestFitHelix(t *testing.T) {
+	const bfield = 2
+	for _, tc := range []struct {
+		name string
+		want Helix
+	}{
+		{
+			name: "positive",
+			want: Helix{D0: 2, Z0: 5, Phi0: 0.3, CotTheta: 0.5, QOverPt: +1},
+		},
+		{
+			name: "negative",
+			want: Helix{D0: -1.5, Z0: -20, Phi0: -2.5, CotTheta: -1.2, QOverPt: -0.5},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			want := tc.want
+			hits := genHelix(12, want.D0, want.Z0, want.Phi0, want.CotTheta, want.QOverPt, bfield)
+			hits[0], hits[5] = hits[5], hits[0]
+
+			f := NewFitter()
+			f.BField = bfield
+			got, err := f.Fit(hits)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, v := range []struct {
+				name      string
+				got, want float64
+			}{
+				{"d0", got.D0, want.D0},
+				{"z0", got.Z0, want.Z0},
+				{"phi0", got.Phi0, want.Phi0},
+				{"cot", got.CotTheta, want.CotTheta},
+				{"q/pt", got.QOverPt, want.QOverPt},
+			} {
+				if !scalar.EqualWithinAbs(v.got, v.want, 1e-6) {
+					t.Errorf("invalid %s: got=%v, want=%v", v.name, v.got, v.want)
+				}
+			}
+
+			if got.NDF != 2*len(hits)-5 {
+				t.Errorf("invalid ndf: got=%d, want=%d", got.NDF, 2*len(hits)-5)
+			}
+			if got.Chi2 > 1e-6 {
+				t.Errorf("invalid chi2: %v", got.Chi2)
+			}
+			for i := 0; i < 5; i++ {
+				if v := got.Cov.At(i, i); !(v > 0) {
+					t.Errorf("invalid variance for parameter %d: %v", i, v)
+				}
+			}
+		})
+	}
+}
+
+func TestOrientation(t *testing.T) {
+	// circle going through the origin, points travelling counter-clockwise
+	// from the origin, towards +x.
+	c := Circle{X0: 0, Y0: 1000, R: 1000}
+	var xs, ys []float64
+	for _, a := range []float64{0.05, 0.1, 0.15, 0.2} {
+		xs = append(xs, c.R*math.Sin(a))
+		ys = append(ys, c.Y0-c.R*math.Cos(a))
+	}
+
+	for _, tc := range []struct {
+		name   string
+		xs, ys []float64
+		want   float64
+	}{
+		{"ccw", xs, ys, +1},
+		{"cw", neg(xs), ys, -1},
+		// an outlier at large radius, behind the first point.
+		{"ccw-outlier", append(xs, -50), append(ys, 300), +1},
+		{"cw-outlier", append(neg(xs), 50), append(ys, 300), -1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := orientation(c, tc.xs, tc.ys)
+			if got != tc.want {
+				t.Fatalf("invalid orientation: got=%v, want=%v", got, tc.want)
+			}
+		})
+	}
+}
+
+func neg(vs []float64) []float64 {
+	o := make([]float64, len(vs))
+	for i, v := range vs {
+		o[i] = -v
+	}
+	return o
+}
+
+func TestSelect(t *testing.T) {
+	hits := genHelix(5, 0, 0, 0, 0, 1, 2)
+	labels := []int{1, trackml.Unassigned, 1, 0, 1}
+	got := Select(hits, labels, 1)
+	if len(got) != 3 || got[0].HitID != 1 || got[1].HitID != 3 || got[2].HitID != 5 {
+		t.Fatalf("invalid selection: %v", got)
+	}
+}
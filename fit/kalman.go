@@ -0,0 +1,502 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fit
+
+import (
+	"math"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/sbinet/go-trackml"
+	"gonum.org/v1/gonum/mat"
+)
+
+// X0Silicon is the radiation length of silicon (mm).
+const X0Silicon = 93.7
+
+// State is a track state, described by the perigee helix parameters
+// (D0, Z0, Phi0, CotTheta, QOverPt) and their covariance matrix.
+type State struct {
+	Params *mat.VecDense
+	Cov    *mat.SymDense
+}
+
+// Helix returns the helix described by the track state.
+func (st State) Helix() Helix {
+	return Helix{
+		D0:       st.Params.AtVec(0),
+		Z0:       st.Params.AtVec(1),
+		Phi0:     st.Params.AtVec(2),
+		CotTheta: st.Params.AtVec(3),
+		QOverPt:  st.Params.AtVec(4),
+		Cov:      st.Cov,
+	}
+}
+
+// TrackState holds the Kalman filter informations at a given hit.
+type TrackState struct {
+	Hit    trackml.Hit
+	Module trackml.Module
+	S      float64 // arc length of the hit along the track, in the transverse plane (mm)
+
+	Predicted State
+	Filtered  State
+	Smoothed  State
+
+	Chi2     float64    // chi2 increment of the filter at this hit
+	Residual [2]float64 // smoothed residuals along the local (u,v) axes of the module (mm)
+	Pull     [2]float64 // smoothed pulls along the local (u,v) axes of the module
+}
+
+// Track is a track fitted by the Kalman filter.
+type Track struct {
+	States []TrackState // track states, ordered along the track
+	Chi2   float64      // total chi2 of the filter
+	NDF    int          // number of degrees of freedom
+}
+
+// Params returns the smoothed track parameters at the first hit.
+func (trk *Track) Params() State {
+	return trk.States[0].Smoothed
+}
+
+// Kalman fits tracks with a Kalman filter and smoother.
+//
+// The track state is described by the perigee parameters of a helix.
+// Measurements are the positions of the hits in the local (u,v) coordinates
+// of their module, obtained by intersecting the helix with the plane of the
+// module.
+// Multiple scattering in the modules is described by a process noise on the
+// direction of the track, following the Highland formula.
+// The magnetic field is the one of the Seed fitter.
+type Kalman struct {
+	Detector *trackml.Detector // detector geometry
+	Seed     *Fitter           // helix fitter providing the initial state and magnetic field
+	X0       float64           // radiation length of the modules material (mm)
+	Mass     float64           // mass hypothesis of the particle (GeV/c^2)
+
+	// SeedScale inflates the covariance matrix of the seed helix.
+	SeedScale float64
+}
+
+// NewKalman returns a Kalman filter for the provided detector, in a 2 Tesla field,
+// with silicon modules and a pion mass hypothesis.
+func NewKalman(det *trackml.Detector) *Kalman {
+	return &Kalman{
+		Detector:  det,
+		Seed:      NewFitter(),
+		X0:        X0Silicon,
+		Mass:      0.13957,
+		SeedScale: 100,
+	}
+}
+
+// Fit fits a track through the provided hits.
+func (kf *Kalman) Fit(hits []trackml.Hit) (Track, error) {
+	var trk Track
+	if len(hits) < 3 {
+		return trk, errTooFewPoints
+	}
+
+	seed, err := kf.Seed.Fit(hits)
+	if err != nil {
+		return trk, errors.Wrapf(err, "fit: could not fit seed helix")
+	}
+	params := mat.NewVecDense(5, []float64{seed.D0, seed.Z0, seed.Phi0, seed.CotTheta, seed.QOverPt})
+	cov := mat.NewSymDense(5, nil)
+	cov.ScaleSym(kf.SeedScale, seed.Cov)
+
+	trk.States = make([]TrackState, len(hits))
+	for i, hit := range hits {
+		mod, ok := kf.Detector.HitModule(hit)
+		if !ok {
+			return trk, errors.Errorf(
+				"fit: no module (vol=%d, layer=%d, module=%d) for hit %d",
+				hit.VolumeID, hit.LayerID, hit.ModuleID, hit.HitID,
+			)
+		}
+		trk.States[i] = TrackState{
+			Hit:    hit,
+			Module: mod,
+			S:      kf.arcLength(params, hit),
+		}
+	}
+	sort.SliceStable(trk.States, func(i, j int) bool {
+		return trk.States[i].S < trk.States[j].S
+	})
+
+	for i := range trk.States {
+		st := &trk.States[i]
+		if i > 0 {
+			prev := &trk.States[i-1]
+			q := kf.noise(prev.Filtered.Params, prev.Module, prev.S)
+			cov = mat.NewSymDense(5, nil)
+			cov.AddSym(prev.Filtered.Cov, q)
+			params = prev.Filtered.Params
+		}
+		st.Predicted = State{Params: params, Cov: cov}
+
+		st.Filtered, st.Chi2, err = kf.update(st.Predicted, st.Module, st.Hit, st.S)
+		if err != nil {
+			return trk, errors.Wrapf(err, "fit: could not update state with hit %d", st.Hit.HitID)
+		}
+		trk.Chi2 += st.Chi2
+	}
+	trk.NDF = 2*len(trk.States) - 5
+
+	err = kf.smooth(trk.States)
+	if err != nil {
+		return trk, errors.Wrapf(err, "fit: could not smooth track")
+	}
+
+	for i := range trk.States {
+		st := &trk.States[i]
+		st.Residual, st.Pull, err = kf.residuals(st.Smoothed, st.Module, st.Hit, st.S)
+		if err != nil {
+			return trk, errors.Wrapf(err, "fit: could not compute residuals of hit %d", st.Hit.HitID)
+		}
+	}
+
+	return trk, nil
+}
+
+// Chi2 returns the chi2 increment of adding the provided hit to the track,
+// from the filtered state at the last hit of the track.
+func (kf *Kalman) Chi2(trk Track, hit trackml.Hit) (float64, error) {
+	if len(trk.States) == 0 {
+		return 0, errors.Errorf("fit: empty track")
+	}
+	mod, ok := kf.Detector.HitModule(hit)
+	if !ok {
+		return 0, errors.Errorf(
+			"fit: no module (vol=%d, layer=%d, module=%d) for hit %d",
+			hit.VolumeID, hit.LayerID, hit.ModuleID, hit.HitID,
+		)
+	}
+	last := trk.States[len(trk.States)-1]
+	cov := mat.NewSymDense(5, nil)
+	cov.AddSym(last.Filtered.Cov, kf.noise(last.Filtered.Params, last.Module, last.S))
+	pred := State{Params: last.Filtered.Params, Cov: cov}
+
+	_, chi2, err := kf.update(pred, mod, hit, kf.arcLength(pred.Params, hit))
+	return chi2, err
+}
+
+// update updates the predicted state with the measurement of the provided hit.
+func (kf *Kalman) update(pred State, mod trackml.Module, hit trackml.Hit, s float64) (State, float64, error) {
+	var (
+		st State
+		m  = measurement(mod, hit)
+		v  = measurementCov(mod)
+	)
+
+	h, r, err := kf.project(pred.Params, mod, m, s)
+	if err != nil {
+		return st, 0, err
+	}
+
+	// residual covariance: V + H C H^T
+	var rcov mat.Dense
+	rcov.Product(h, pred.Cov, h.T())
+	rcov.Add(&rcov, v)
+
+	var rinv mat.Dense
+	if err := rinv.Inverse(&rcov); err != nil {
+		return st, 0, errDegenerate
+	}
+
+	// gain: K = C H^T (V + H C H^T)^-1
+	var k mat.Dense
+	k.Product(pred.Cov, h.T(), &rinv)
+
+	params := mat.NewVecDense(5, nil)
+	params.MulVec(&k, r)
+	params.AddVec(params, pred.Params)
+
+	// C = (1 - K H) C
+	var kh mat.Dense
+	kh.Mul(&k, h)
+	var ikh mat.Dense
+	ikh.Sub(eye(5), &kh)
+	var c mat.Dense
+	c.Mul(&ikh, pred.Cov)
+	st = State{Params: params, Cov: symmetrize(&c)}
+
+	// chi2 of the filtered residuals.
+	_, rf, err := kf.project(params, mod, m, s)
+	if err != nil {
+		return st, 0, err
+	}
+	var rfcov mat.Dense
+	rfcov.Product(h, &c, h.T())
+	rfcov.Sub(v, &rfcov)
+	var rfinv mat.Dense
+	if err := rfinv.Inverse(&rfcov); err != nil {
+		return st, 0, errDegenerate
+	}
+	chi2 := mat.Inner(rf, &rfinv, rf)
+
+	return st, chi2, nil
+}
+
+// smooth runs the Rauch-Tung-Striebel smoother on the filtered states.
+func (kf *Kalman) smooth(states []TrackState) error {
+	n := len(states)
+	states[n-1].Smoothed = states[n-1].Filtered
+	for i := n - 2; i >= 0; i-- {
+		var (
+			cur  = &states[i]
+			next = &states[i+1]
+		)
+
+		// A = C_f C_p(next)^-1
+		var pinv mat.Dense
+		if err := pinv.Inverse(next.Predicted.Cov); err != nil {
+			return errDegenerate
+		}
+		var a mat.Dense
+		a.Mul(cur.Filtered.Cov, &pinv)
+
+		var dp mat.VecDense
+		dp.SubVec(next.Smoothed.Params, next.Predicted.Params)
+		params := mat.NewVecDense(5, nil)
+		params.MulVec(&a, &dp)
+		params.AddVec(params, cur.Filtered.Params)
+
+		var dc mat.Dense
+		dc.Sub(next.Smoothed.Cov, next.Predicted.Cov)
+		var c mat.Dense
+		c.Product(&a, &dc, a.T())
+		c.Add(&c, cur.Filtered.Cov)
+
+		cur.Smoothed = State{Params: params, Cov: symmetrize(&c)}
+	}
+	return nil
+}
+
+// residuals returns the residuals and pulls of the hit with respect to
+// the smoothed state.
+func (kf *Kalman) residuals(st State, mod trackml.Module, hit trackml.Hit, s float64) (res, pull [2]float64, err error) {
+	var (
+		m = measurement(mod, hit)
+		v = measurementCov(mod)
+	)
+	h, r, err := kf.project(st.Params, mod, m, s)
+	if err != nil {
+		return res, pull, err
+	}
+	var hch mat.Dense
+	hch.Product(h, st.Cov, h.T())
+	for i := range res {
+		res[i] = r.AtVec(i)
+		pull[i] = res[i] / math.Sqrt(math.Abs(v.At(i, i)-hch.At(i, i)))
+	}
+	return res, pull, nil
+}
+
+// project returns the jacobian matrix of the (u,v) local coordinates of the
+// intersection of the helix with the module plane, with respect to the helix
+// parameters, and the residual vector of the measurement m.
+func (kf *Kalman) project(params *mat.VecDense, mod trackml.Module, m [2]float64, s float64) (*mat.Dense, *mat.VecDense, error) {
+	var p [5]float64
+	for i := range p {
+		p[i] = params.AtVec(i)
+	}
+	uv, err := kf.intersect(p, mod, s)
+	if err != nil {
+		return nil, nil, err
+	}
+	r := mat.NewVecDense(2, []float64{m[0] - uv[0], m[1] - uv[1]})
+
+	h := mat.NewDense(2, 5, nil)
+	for j := range p {
+		eps := 1e-6 * math.Max(1, math.Abs(p[j]))
+		if j == 4 {
+			eps = 1e-6 * math.Max(1e-3, math.Abs(p[j]))
+		}
+		hi := p
+		lo := p
+		hi[j] += eps
+		lo[j] -= eps
+		uhi, err := kf.intersect(hi, mod, s)
+		if err != nil {
+			return nil, nil, err
+		}
+		ulo, err := kf.intersect(lo, mod, s)
+		if err != nil {
+			return nil, nil, err
+		}
+		for i := range uhi {
+			h.Set(i, j, (uhi[i]-ulo[i])/(2*eps))
+		}
+	}
+	return h, r, nil
+}
+
+// intersect returns the local (u,v) coordinates of the intersection of the
+// helix with the plane of the module, starting the search at arc length s.
+func (kf *Kalman) intersect(p [5]float64, mod trackml.Module, s float64) ([2]float64, error) {
+	var (
+		w   = mod.W()
+		pos [3]float64
+	)
+	for iter := 0; iter < 20; iter++ {
+		var dir [3]float64
+		pos, dir = kf.point(p, s)
+		_, _, dist := mod.Local(pos[0], pos[1], pos[2])
+		dot := dir[0]*w[0] + dir[1]*w[1] + dir[2]*w[2]
+		if dot == 0 {
+			return [2]float64{}, errors.Errorf("fit: track parallel to module")
+		}
+		ds := dist / dot
+		s -= ds
+		if math.Abs(ds) < 1e-9 {
+			break
+		}
+	}
+	pos, _ = kf.point(p, s)
+	u, v, _ := mod.Local(pos[0], pos[1], pos[2])
+	return [2]float64{u, v}, nil
+}
+
+// point returns the position of the helix at the arc length s in the
+// transverse plane, and the derivative of that position with respect to s.
+func (kf *Kalman) point(p [5]float64, s float64) (pos, dir [3]float64) {
+	var (
+		d0   = p[0]
+		z0   = p[1]
+		phi0 = p[2]
+		cot  = p[3]
+		h    = math.Copysign(1, p[4]*kf.Seed.BField) // +1 for clockwise motion.
+		rho  = math.Abs(p[4]) * ptFactor * math.Abs(kf.Seed.BField)
+		phi  = phi0 - h*rho*s
+		sin0 = math.Sin(phi0)
+		cos0 = math.Cos(phi0)
+	)
+	switch {
+	case rho*math.Abs(s) < 1e-9:
+		pos[0] = -d0*sin0 + s*cos0
+		pos[1] = +d0*cos0 + s*sin0
+	default:
+		r := 1 / rho
+		pos[0] = -d0*sin0 + h*r*(math.Sin(phi0)-math.Sin(phi))
+		pos[1] = +d0*cos0 - h*r*(math.Cos(phi0)-math.Cos(phi))
+	}
+	pos[2] = z0 + cot*s
+	dir = [3]float64{math.Cos(phi), math.Sin(phi), cot}
+	return pos, dir
+}
+
+// arcLength returns the arc length along the helix of the point of closest
+// approach to the provided hit, in the transverse plane.
+func (kf *Kalman) arcLength(params *mat.VecDense, hit trackml.Hit) float64 {
+	var p [5]float64
+	for i := range p {
+		p[i] = params.AtVec(i)
+	}
+	s := math.Hypot(hit.X, hit.Y)
+	for iter := 0; iter < 20; iter++ {
+		pos, dir := kf.point(p, s)
+		dx := hit.X - pos[0]
+		dy := hit.Y - pos[1]
+		ds := dx*dir[0] + dy*dir[1]
+		s += ds
+		if math.Abs(ds) < 1e-9 {
+			break
+		}
+	}
+	return s
+}
+
+// noise returns the process noise covariance matrix due to multiple
+// scattering in the provided module, at arc length s.
+func (kf *Kalman) noise(params *mat.VecDense, mod trackml.Module, s float64) *mat.SymDense {
+	var (
+		p [5]float64
+		q = mat.NewSymDense(5, nil)
+	)
+	for i := range p {
+		p[i] = params.AtVec(i)
+	}
+	if p[4] == 0 || kf.X0 <= 0 {
+		return q
+	}
+
+	var (
+		cot  = p[3]
+		cot2 = 1 + cot*cot
+		pt   = 1 / math.Abs(p[4])
+		mom  = pt * math.Sqrt(cot2)
+		beta = mom / math.Hypot(mom, kf.Mass)
+
+		_, dir = kf.point(p, s)
+		w      = mod.W()
+		cosInc = math.Abs(dir[0]*w[0]+dir[1]*w[1]+dir[2]*w[2]) / math.Sqrt(cot2)
+	)
+	if cosInc < 1e-3 {
+		cosInc = 1e-3
+	}
+	xx0 := 2 * mod.HalfT / (kf.X0 * cosInc)
+	if xx0 <= 0 {
+		return q
+	}
+	theta0 := 0.0136 / (beta * mom) * math.Sqrt(xx0) * (1 + 0.038*math.Log(xx0))
+	theta2 := theta0 * theta0
+
+	// jacobians of the perigee parameters with respect to a kick of the
+	// direction of the track at arc length s, in the transverse plane
+	// and in the polar angle.
+	var (
+		gphi = [5]float64{-s, 0, 1, 0, 0}
+		gth  = [5]float64{0, s * cot2, 0, -cot2, -p[4] * cot}
+	)
+	for i := 0; i < 5; i++ {
+		for j := i; j < 5; j++ {
+			v := cot2 * theta2 * gphi[i] * gphi[j]
+			v += theta2 * gth[i] * gth[j]
+			q.SetSym(i, j, v)
+		}
+	}
+	return q
+}
+
+// measurement returns the local (u,v) coordinates of the hit in its module.
+func measurement(mod trackml.Module, hit trackml.Hit) [2]float64 {
+	u, v, _ := mod.Local(hit.X, hit.Y, hit.Z)
+	return [2]float64{u, v}
+}
+
+// measurementCov returns the covariance matrix of a measurement in the
+// provided module, derived from the pitch of its cells.
+func measurementCov(mod trackml.Module) *mat.SymDense {
+	const (
+		min  = 1e-3 // mm
+		inv  = 1.0 / 12
+		min2 = min * min
+	)
+	return mat.NewSymDense(2, []float64{
+		math.Max(mod.PitchU*mod.PitchU*inv, min2), 0,
+		0, math.Max(mod.PitchV*mod.PitchV*inv, min2),
+	})
+}
+
+func eye(n int) *mat.Dense {
+	m := mat.NewDense(n, n, nil)
+	for i := 0; i < n; i++ {
+		m.Set(i, i, 1)
+	}
+	return m
+}
+
+func symmetrize(m *mat.Dense) *mat.SymDense {
+	n, _ := m.Dims()
+	o := mat.NewSymDense(n, nil)
+	for i := 0; i < n; i++ {
+		for j := i; j < n; j++ {
+			o.SetSym(i, j, 0.5*(m.At(i, j)+m.At(j, i)))
+		}
+	}
+	return o
+}
@@ -0,0 +1,156 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fit
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/sbinet/go-trackml"
+	"gonum.org/v1/gonum/floats/scalar"
+)
+
+// genBarrel returns a detector made of one barrel module per hit, with the
+// module normal along the radial direction of the hit.
+// Hits are moved to their module.
+func genBarrel(hits []trackml.Hit) *trackml.Detector {
+	mods := make([]trackml.Module, len(hits))
+	for i := range hits {
+		hit := &hits[i]
+		hit.VolumeID = 8
+		hit.LayerID = 2 * (i + 1)
+		hit.ModuleID = i + 1
+
+		phi := math.Atan2(hit.Y, hit.X)
+		sin, cos := math.Sin(phi), math.Cos(phi)
+		mods[i] = trackml.Module{
+			VolumeID: hit.VolumeID,
+			LayerID:  hit.LayerID,
+			ModuleID: hit.ModuleID,
+			// shift the module center along its (u,v) axes.
+			Cx:     hit.X - 0.3*sin,
+			Cy:     hit.Y + 0.3*cos,
+			Cz:     hit.Z - 1,
+			RotXU:  -sin,
+			RotYU:  +cos,
+			RotZV:  1,
+			RotXW:  cos,
+			RotYW:  sin,
+			HalfT:  0.075,
+			PitchU: 0.05,
+			PitchV: 0.05,
+		}
+	}
+	return trackml.NewDetector(mods)
+}
+
+func TestKalmanPerfect(t *testing.T) {
+	for _, bfield := range []float64{2, 4} {
+		t.Run(fmt.Sprintf("b=%v", bfield), func(t *testing.T) {
+			want := Helix{D0: 0.5, Z0: 5, Phi0: 0.3, CotTheta: 0.5, QOverPt: -1}
+			hits := genHelix(10, want.D0, want.Z0, want.Phi0, want.CotTheta, want.QOverPt, bfield)
+			det := genBarrel(hits)
+			hits[2], hits[7] = hits[7], hits[2]
+
+			kf := NewKalman(det)
+			kf.Seed.BField = bfield
+			trk, err := kf.Fit(hits)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := trk.NDF, 2*len(hits)-5; got != want {
+				t.Fatalf("invalid ndf: got=%d, want=%d", got, want)
+			}
+			if trk.Chi2 > 1e-3 {
+				t.Fatalf("invalid chi2: %v", trk.Chi2)
+			}
+
+			for i, st := range trk.States {
+				if i > 0 && st.S < trk.States[i-1].S {
+					t.Fatalf("track states not ordered")
+				}
+				for j := range st.Pull {
+					if math.Abs(st.Residual[j]) > 1e-6 || math.Abs(st.Pull[j]) > 1e-3 {
+						t.Fatalf("invalid residual/pull for state %d: %v %v", i, st.Residual, st.Pull)
+					}
+				}
+			}
+
+			got := trk.Params().Helix()
+			for _, v := range []struct {
+				name      string
+				got, want float64
+			}{
+				{"d0", got.D0, want.D0},
+				{"z0", got.Z0, want.Z0},
+				{"phi0", got.Phi0, want.Phi0},
+				{"cot", got.CotTheta, want.CotTheta},
+				{"q/pt", got.QOverPt, want.QOverPt},
+			} {
+				if !scalar.EqualWithinAbs(v.got, v.want, 1e-5) {
+					t.Errorf("invalid %s: got=%v, want=%v", v.name, v.got, v.want)
+				}
+			}
+
+			// a hit displaced by 1mm along u is incompatible with the track.
+			last := trk.States[len(trk.States)-1]
+			hit := last.Hit
+			hit.X += 1 * last.Module.RotXU
+			hit.Y += 1 * last.Module.RotYU
+			chi2, err := kf.Chi2(trk, hit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if chi2 < 100 {
+				t.Fatalf("invalid chi2 increment for displaced hit: %v", chi2)
+			}
+		})
+	}
+}
+
+func TestKalmanPulls(t *testing.T) {
+	const (
+		bfield = 2
+		ntrks  = 50
+	)
+	var (
+		rnd   = rand.New(rand.NewSource(1234))
+		sigma = 0.05 / math.Sqrt(12)
+		sum   float64
+		n     int
+	)
+	for itrk := 0; itrk < ntrks; itrk++ {
+		hits := genHelix(10, 0, 10*rnd.NormFloat64(), 2*math.Pi*rnd.Float64(), rnd.NormFloat64(), 2*rnd.Float64()-1, bfield)
+		det := genBarrel(hits)
+		for i := range hits {
+			mod := det.Modules[i]
+			du := sigma * rnd.NormFloat64()
+			dv := sigma * rnd.NormFloat64()
+			hits[i].X += du*mod.RotXU + dv*mod.RotXV
+			hits[i].Y += du*mod.RotYU + dv*mod.RotYV
+			hits[i].Z += du*mod.RotZU + dv*mod.RotZV
+		}
+
+		kf := NewKalman(det)
+		kf.X0 = 0 // no multiple scattering.
+		trk, err := kf.Fit(hits)
+		if err != nil {
+			t.Fatalf("track %d: %+v", itrk, err)
+		}
+		for _, st := range trk.States {
+			for _, p := range st.Pull {
+				sum += p * p
+				n++
+			}
+		}
+	}
+
+	rms := math.Sqrt(sum / float64(n))
+	if rms < 0.8 || 1.2 < rms {
+		t.Fatalf("invalid pulls RMS: %v", rms)
+	}
+}
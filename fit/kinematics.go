@@ -0,0 +1,100 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fit
+
+import (
+	"math"
+
+	"github.com/sbinet/go-trackml"
+)
+
+// Kinematics describes the momentum and charge of a track.
+type Kinematics struct {
+	Pt  float64 // transverse momentum (GeV/c)
+	Eta float64 // pseudo-rapidity
+	Phi float64 // azimuthal angle of the momentum (rad)
+	Q   int     // charge
+}
+
+// Kinematics returns the kinematics of the track described by the helix,
+// at its point of closest approach.
+func (hlx Helix) Kinematics() Kinematics {
+	kin := Kinematics{
+		Pt:  math.Inf(+1),
+		Eta: math.Asinh(hlx.CotTheta),
+		Phi: hlx.Phi0,
+	}
+	switch {
+	case hlx.QOverPt > 0:
+		kin.Q = +1
+	case hlx.QOverPt < 0:
+		kin.Q = -1
+	}
+	if hlx.QOverPt != 0 {
+		kin.Pt = 1 / math.Abs(hlx.QOverPt)
+	}
+	return kin
+}
+
+// ParticleKinematics returns the kinematics of the provided particle,
+// at its production vertex.
+func ParticleKinematics(p trackml.Particle) Kinematics {
+	pt := math.Hypot(p.Px, p.Py)
+	return Kinematics{
+		Pt:  pt,
+		Eta: math.Asinh(p.Pz / pt),
+		Phi: math.Atan2(p.Py, p.Px),
+		Q:   p.Q,
+	}
+}
+
+// Match associates the estimated kinematics of a reconstructed track with
+// the ones of the particle contributing the majority of its hits.
+type Match struct {
+	trackml.TrackMatch
+
+	Helix Helix      // fitted helix of the reconstructed track
+	Reco  Kinematics // estimated kinematics of the reconstructed track
+	True  Kinematics // kinematics of the majority particle
+}
+
+// MatchKinematics fits a helix through each reconstructed track of the event,
+// and associates its kinematics with the ones of the majority particle.
+//
+// Tracks whose majority particle is noise, or whose helix fit fails, are
+// skipped.
+func (f *Fitter) MatchKinematics(evt trackml.Event, labels []int) []Match {
+	ps := make(map[int]trackml.Particle, len(evt.Ps))
+	for _, p := range evt.Ps {
+		ps[p.ID] = p
+	}
+
+	hits := make(map[int][]trackml.Hit)
+	for i, label := range labels {
+		if label == trackml.Unassigned {
+			continue
+		}
+		hits[label] = append(hits[label], evt.Hits[i])
+	}
+
+	var ms []Match
+	for _, trk := range trackml.MatchTracks(evt, labels) {
+		p, ok := ps[trk.PID]
+		if !ok {
+			continue
+		}
+		hlx, err := f.Fit(hits[trk.TrackID])
+		if err != nil {
+			continue
+		}
+		ms = append(ms, Match{
+			TrackMatch: trk,
+			Helix:      hlx,
+			Reco:       hlx.Kinematics(),
+			True:       ParticleKinematics(p),
+		})
+	}
+	return ms
+}
@@ -0,0 +1,64 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fit
+
+import (
+	"math"
+	"testing"
+
+	"github.com/sbinet/go-trackml"
+	"gonum.org/v1/gonum/floats/scalar"
+)
+
+func TestMatchKinematics(t *testing.T) {
+	const bfield = 2
+	var evt trackml.Event
+	for _, p := range []trackml.Particle{
+		{ID: 11, Q: +1, Px: 1 * math.Cos(0.3), Py: 1 * math.Sin(0.3), Pz: 0.5},
+		{ID: 22, Q: -1, Px: 2 * math.Cos(-1), Py: 2 * math.Sin(-1), Pz: -0.4},
+	} {
+		kin := ParticleKinematics(p)
+		hits := genHelix(8, 0, 0, kin.Phi, math.Sinh(kin.Eta), float64(kin.Q)/kin.Pt, bfield)
+		for _, hit := range hits {
+			hit.HitID = len(evt.Hits) + 1
+			evt.Hits = append(evt.Hits, hit)
+			evt.Mcs = append(evt.Mcs, trackml.Truth{HitID: hit.HitID, PID: p.ID, Weight: 1})
+		}
+		p.NHits = len(hits)
+		evt.Ps = append(evt.Ps, p)
+	}
+	labels := make([]int, len(evt.Hits))
+	for i := range labels {
+		labels[i] = i / 8
+	}
+	labels[0] = trackml.Unassigned
+
+	f := NewFitter()
+	f.BField = bfield
+	ms := f.MatchKinematics(evt, labels)
+	if len(ms) != 2 {
+		t.Fatalf("invalid number of matches: got=%d, want=2", len(ms))
+	}
+	for i, m := range ms {
+		if m.TrackID != i {
+			t.Fatalf("invalid track ID: got=%d, want=%d", m.TrackID, i)
+		}
+		if m.Reco.Q != m.True.Q {
+			t.Errorf("track %d: invalid charge: got=%d, want=%d", i, m.Reco.Q, m.True.Q)
+		}
+		for _, v := range []struct {
+			name      string
+			got, want float64
+		}{
+			{"pt", m.Reco.Pt, m.True.Pt},
+			{"eta", m.Reco.Eta, m.True.Eta},
+			{"phi", m.Reco.Phi, m.True.Phi},
+		} {
+			if !scalar.EqualWithinAbs(v.got, v.want, 1e-6) {
+				t.Errorf("track %d: invalid %s: got=%v, want=%v", i, v.name, v.got, v.want)
+			}
+		}
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fit
+
+import (
+	"gonum.org/v1/gonum/mat"
+)
+
+// Line is a straight line z = Z0 + Slope*s in the s-z plane,
+// where s is the arc length along the track in the transverse plane.
+type Line struct {
+	Z0    float64 // z intercept at s=0 (mm)
+	Slope float64 // dz/ds, i.e. the cotangent of the polar angle
+
+	Cov  *mat.SymDense // covariance matrix of (Z0, Slope)
+	Chi2 float64       // chi2 of the fit
+	NDF  int           // number of degrees of freedom of the fit
+}
+
+// LineSZ fits a straight line through the provided (s,z) points, with a
+// resolution sigma on z.
+func LineSZ(ss, zs []float64, sigma float64) (Line, error) {
+	if err := checkPoints(ss, zs, 2); err != nil {
+		return Line{}, err
+	}
+
+	w := 1 / (sigma * sigma)
+	var sw, sx, sy, sxx, sxy float64
+	for i := range ss {
+		s := ss[i]
+		z := zs[i]
+		sw += w
+		sx += w * s
+		sy += w * z
+		sxx += w * s * s
+		sxy += w * s * z
+	}
+	det := sw*sxx - sx*sx
+	if det == 0 {
+		return Line{}, errDegenerate
+	}
+
+	line := Line{
+		Z0:    (sxx*sy - sx*sxy) / det,
+		Slope: (sw*sxy - sx*sy) / det,
+		Cov:   mat.NewSymDense(2, []float64{sxx / det, -sx / det, -sx / det, sw / det}),
+		NDF:   len(ss) - 2,
+	}
+	for i := range ss {
+		d := (zs[i] - line.Z0 - line.Slope*ss[i]) / sigma
+		line.Chi2 += d * d
+	}
+	return line, nil
+}
@@ -0,0 +1,38 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package trackml
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// osFS is the local file system.
+//
+// Unlike os.DirFS, it accepts any OS path, absolute or relative to the
+// current directory, as is expected by NewDataset and ReadEvent.
+type osFS struct{}
+
+func (osFS) Open(name string) (fs.File, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (osFS) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (osFS) Glob(pattern string) ([]string, error) {
+	return filepath.Glob(pattern)
+}
+
+var (
+	_ fs.StatFS = osFS{}
+	_ fs.GlobFS = osFS{}
+)
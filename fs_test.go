@@ -0,0 +1,155 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package trackml
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"github.com/sbinet/go-trackml/internal/testevent"
+)
+
+func TestDatasetFS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "trkml-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	evt := testevent.New(100, 10)
+	_, err = evt.Write(dir, "event000000001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := ReadMcEvent(dir, "event000000001")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := evt.Files("event000000001")
+
+	mapfs := make(fstest.MapFS)
+	for name, raw := range files {
+		var buf bytes.Buffer
+		err := writeCompressed(&buf, gzipFile, raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mapfs["data/"+name+".gz"] = &fstest.MapFile{Data: buf.Bytes()}
+	}
+
+	tgz := filepath.Join(dir, "data.tar.gz")
+	err = writeTar(files, gzipFile)(tgz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := ioutil.ReadFile(tgz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mapfs["archives/data.tar.gz"] = &fstest.MapFile{Data: raw}
+
+	zname := filepath.Join(dir, "data.zip")
+	err = writeZip(files, "", nil)(zname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err = ioutil.ReadFile(zname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mapfs["archives/data.zip"] = &fstest.MapFile{Data: raw}
+	zr, err := zip.NewReader(bytes.NewReader(raw), int64(len(raw)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name string
+		fsys fs.FS
+		path string
+	}{
+		{"map-dir", mapfs, "data"},
+		{"map-tgz", mapfs, "archives/data.tar.gz"},
+		{"map-zip", mapfs, "archives/data.zip"},
+		{"zip-reader", zr, "dataset"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ds, err := NewDatasetFS(tc.fsys, tc.path, 0, -1, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer ds.Close()
+
+			n := 0
+			for ds.Next() {
+				if got := ds.Event(); !reflect.DeepEqual(got, want) {
+					t.Fatalf("events differ")
+				}
+				n++
+			}
+			if err := ds.Err(); err != nil {
+				t.Fatal(err)
+			}
+			if n != 1 {
+				t.Fatalf("invalid number of events: got=%d, want=1", n)
+			}
+
+			got, err := ReadEventFS(tc.fsys, tc.path, "event000000001")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got.Hits, want.Hits) || !reflect.DeepEqual(got.Cells, want.Cells) {
+				t.Fatalf("events differ")
+			}
+
+			hdrs, err := ReadHeadersFS(tc.fsys, tc.path, "event000000001")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(hdrs["truth"], Schemas["truth"]) {
+				t.Fatalf("invalid truth header:\ngot = %v\nwant= %v", hdrs["truth"], Schemas["truth"])
+			}
+		})
+	}
+}
+
+func TestDatasetIncomplete(t *testing.T) {
+	mapfs := make(fstest.MapFS)
+	for _, evtid := range []string{"event000000001", "event000000002"} {
+		for name, raw := range testevent.New(10, 5).Files(evtid) {
+			mapfs["data/"+name] = &fstest.MapFile{Data: raw}
+		}
+	}
+	delete(mapfs, "data/event000000002-hits.csv")
+	mapfs["data/detectors.csv"] = &fstest.MapFile{Data: []byte("volume_id\n")}
+	mapfs["data/event000000001-blacklist_hits.csv"] = &fstest.MapFile{Data: []byte("hit_id\n")}
+
+	ds, err := NewDatasetFS(mapfs, "data", 0, -1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ds.Close()
+
+	// events with missing files are listed, and fail to be read.
+	want := []string{"data/event000000001", "data/event000000002"}
+	if got := ds.Names(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("invalid events:\ngot = %v\nwant= %v", got, want)
+	}
+	n := 0
+	for ds.Next() {
+		n++
+	}
+	if n != 1 || ds.Err() == nil {
+		t.Fatalf("expected an error after the first event (n=%d)", n)
+	}
+}
@@ -0,0 +1,283 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package hitgraph builds graphs of hits, suitable as inputs to graph neural
+// networks classifying track segments.
+//
+// The nodes of a graph are the hits of an event.
+// The edges of a graph are candidate segments between hits on consecutive
+// layers, as given by a layer graph.
+package hitgraph // import "github.com/sbinet/go-trackml/hitgraph"
+
+import (
+	"io"
+	"math"
+
+	"github.com/pkg/errors"
+	"github.com/sbinet/go-trackml"
+	"github.com/sbinet/go-trackml/layers"
+	"github.com/sbinet/go-trackml/seeding"
+	"github.com/sbinet/npyio/npz"
+	"gonum.org/v1/gonum/mat"
+)
+
+// NodeFeatures are the names of the columns of Graph.Nodes.
+var NodeFeatures = []string{"r", "phi", "z", "ncells", "charge"}
+
+// EdgeFeatures are the names of the columns of Graph.Edges.
+var EdgeFeatures = []string{"dr", "dphi", "dz", "deta"}
+
+// Graph is a graph of hits.
+type Graph struct {
+	HitIDs    []int      // hit IDs of the nodes
+	Nodes     *mat.Dense // node features, one row per hit
+	Senders   []int      // index of the inner node of each edge
+	Receivers []int      // index of the outer node of each edge
+	Edges     *mat.Dense // edge features, one row per edge (nil if there are no edges)
+	Labels    []bool     // whether both nodes of each edge belong to the same particle
+}
+
+// Builder builds hit graphs.
+//
+// Candidate edges are the doublets of the seeder, restricted by its
+// geometric cuts.
+type Builder struct {
+	Seeder *seeding.Seeder
+}
+
+// New returns a builder connecting hits on the layers of the provided
+// layer graph.
+func New(g *layers.Graph) *Builder {
+	return &Builder{Seeder: seeding.New(g)}
+}
+
+// Build returns the hit graph of the provided event.
+// Edges are labeled from the Monte-Carlo truth, if any.
+func (b *Builder) Build(evt trackml.Event) Graph {
+	type cellSum struct {
+		n int
+		q float64
+	}
+	cells := make(map[int]cellSum, len(evt.Hits))
+	for _, cell := range evt.Cells {
+		c := cells[cell.HitID]
+		c.n++
+		c.q += cell.Value
+		cells[cell.HitID] = c
+	}
+
+	g := Graph{
+		HitIDs: make([]int, len(evt.Hits)),
+	}
+	if len(evt.Hits) > 0 {
+		g.Nodes = mat.NewDense(len(evt.Hits), len(NodeFeatures), nil)
+	}
+	for i, hit := range evt.Hits {
+		c := cells[hit.HitID]
+		g.HitIDs[i] = hit.HitID
+		g.Nodes.SetRow(i, []float64{
+			math.Hypot(hit.X, hit.Y),
+			math.Atan2(hit.Y, hit.X),
+			hit.Z,
+			float64(c.n),
+			c.q,
+		})
+	}
+
+	doublets := b.Seeder.Doublets(evt.Hits)
+	if len(doublets) == 0 {
+		return g
+	}
+
+	g.Senders = make([]int, len(doublets))
+	g.Receivers = make([]int, len(doublets))
+	g.Labels = make([]bool, len(doublets))
+	g.Edges = mat.NewDense(len(doublets), len(EdgeFeatures), nil)
+	var pids []int
+	if len(evt.Mcs) > 0 {
+		pids = evt.ParticleIDs()
+	}
+	for k, d := range doublets {
+		i, j := d.Hits[0], d.Hits[1]
+		g.Senders[k] = i
+		g.Receivers[k] = j
+		if pids != nil {
+			g.Labels[k] = pids[i] != 0 && pids[i] == pids[j]
+		}
+		var (
+			ri = g.Nodes.At(i, 0)
+			rj = g.Nodes.At(j, 0)
+			zi = g.Nodes.At(i, 2)
+			zj = g.Nodes.At(j, 2)
+		)
+		g.Edges.SetRow(k, []float64{
+			rj - ri,
+			math.Remainder(g.Nodes.At(j, 1)-g.Nodes.At(i, 1), 2*math.Pi),
+			zj - zi,
+			eta(rj, zj) - eta(ri, zi),
+		})
+	}
+	return g
+}
+
+// EdgeInputs returns the inputs of edge classifiers, with one row per edge.
+// Each row holds the node features of the sender, the node features of the
+// receiver and the edge features.
+// EdgeInputs returns nil if the graph has no edges.
+func (g Graph) EdgeInputs() *mat.Dense {
+	if g.Edges == nil {
+		return nil
+	}
+	var (
+		nn = len(NodeFeatures)
+		ne = len(EdgeFeatures)
+		x  = mat.NewDense(len(g.Senders), 2*nn+ne, nil)
+	)
+	for k, i := range g.Senders {
+		row := x.RawRowView(k)
+		copy(row[:nn], g.Nodes.RawRowView(i))
+		copy(row[nn:2*nn], g.Nodes.RawRowView(g.Receivers[k]))
+		copy(row[2*nn:], g.Edges.RawRowView(k))
+	}
+	return x
+}
+
+// eta returns the pseudo-rapidity of a point at radius r and position z.
+func eta(r, z float64) float64 {
+	return math.Asinh(z / r)
+}
+
+// WriteNPZ writes the graph to w in the NumPy compressed data format.
+//
+// The archive contains the arrays hit_id, node_features, edge_senders,
+// edge_receivers, edge_features and edge_labels.
+func (g Graph) WriteNPZ(w io.Writer) error {
+	var (
+		nodes interface{} = []float64{}
+		edges interface{} = []float64{}
+	)
+	if g.Nodes != nil {
+		nodes = g.Nodes
+	}
+	if g.Edges != nil {
+		edges = g.Edges
+	}
+
+	wz := npz.NewWriter(w)
+	for _, v := range []struct {
+		name string
+		data interface{}
+	}{
+		{"hit_id", int64s(g.HitIDs)},
+		{"node_features", nodes},
+		{"edge_senders", int64s(g.Senders)},
+		{"edge_receivers", int64s(g.Receivers)},
+		{"edge_features", edges},
+		{"edge_labels", append([]bool{}, g.Labels...)},
+	} {
+		err := wz.Write(v.name+".npy", v.data)
+		if err != nil {
+			return errors.Wrapf(err, "hitgraph: could not write %q", v.name)
+		}
+	}
+
+	err := wz.Close()
+	if err != nil {
+		return errors.Wrapf(err, "hitgraph: could not close npz archive")
+	}
+	return nil
+}
+
+func int64s(vs []int) []int64 {
+	o := make([]int64, len(vs))
+	for i, v := range vs {
+		o[i] = int64(v)
+	}
+	return o
+}
+
+// Stats summarizes the efficiency and purity of the edges of a graph.
+type Stats struct {
+	Edges    int // number of edges
+	True     int // number of edges between hits of the same particle
+	Segments int // number of true segments, between consecutive layers crossed by a particle
+	Found    int // number of true segments present in the graph
+}
+
+// Efficiency returns the fraction of true segments present in the graph.
+func (st Stats) Efficiency() float64 {
+	if st.Segments == 0 {
+		return 0
+	}
+	return float64(st.Found) / float64(st.Segments)
+}
+
+// Purity returns the fraction of true edges.
+func (st Stats) Purity() float64 {
+	if st.Edges == 0 {
+		return 0
+	}
+	return float64(st.True) / float64(st.Edges)
+}
+
+// Add accumulates the statistics of another graph.
+func (st *Stats) Add(o Stats) {
+	st.Edges += o.Edges
+	st.True += o.True
+	st.Segments += o.Segments
+	st.Found += o.Found
+}
+
+// Evaluate computes the edge efficiency and purity of the graph built from
+// the provided event.
+//
+// True segments connect consecutive hits of a particle on different layers.
+// Hits of a particle on the same layer are all connected to the hits of the
+// previous and next layers.
+func Evaluate(evt trackml.Event, g Graph) Stats {
+	st := Stats{Edges: len(g.Senders)}
+
+	edges := make(map[[2]int]struct{}, len(g.Senders))
+	for k, i := range g.Senders {
+		if g.Labels[k] {
+			st.True++
+		}
+		edges[[2]int{i, g.Receivers[k]}] = struct{}{}
+	}
+
+	for _, traj := range layers.Trajectories(evt) {
+		for _, seg := range segments(evt.Hits, traj) {
+			st.Segments++
+			_, ok := edges[seg]
+			_, rev := edges[[2]int{seg[1], seg[0]}]
+			if ok || rev {
+				st.Found++
+			}
+		}
+	}
+	return st
+}
+
+// segments returns the pairs of hits of a trajectory on consecutive layers.
+func segments(hits []trackml.Hit, traj []int) [][2]int {
+	// group consecutive hits on the same layer.
+	var groups [][]int
+	for k, i := range traj {
+		if k > 0 && layers.HitLayer(hits[i]) == layers.HitLayer(hits[traj[k-1]]) {
+			groups[len(groups)-1] = append(groups[len(groups)-1], i)
+			continue
+		}
+		groups = append(groups, []int{i})
+	}
+
+	var segs [][2]int
+	for k := 1; k < len(groups); k++ {
+		for _, i := range groups[k-1] {
+			for _, j := range groups[k] {
+				segs = append(segs, [2]int{i, j})
+			}
+		}
+	}
+	return segs
+}
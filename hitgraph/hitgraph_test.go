@@ -0,0 +1,147 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hitgraph
+
+import (
+	"bytes"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/sbinet/go-trackml"
+	"github.com/sbinet/go-trackml/internal/testhelix"
+	"github.com/sbinet/go-trackml/layers"
+	"github.com/sbinet/npyio/npz"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestBuild(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1234))
+	evt := testhelix.New(rnd, 50, 200, 2)
+
+	lg := layers.New()
+	lg.Add(evt)
+
+	g := New(lg).Build(evt)
+	if r, c := g.Nodes.Dims(); r != len(evt.Hits) || c != len(NodeFeatures) {
+		t.Fatalf("invalid node features shape: (%d,%d)", r, c)
+	}
+	if r, c := g.Edges.Dims(); r != len(g.Senders) || c != len(EdgeFeatures) {
+		t.Fatalf("invalid edge features shape: (%d,%d)", r, c)
+	}
+	for i := range evt.Hits {
+		if got, want := mat.Row(nil, i, g.Nodes)[3:], []float64{2, 0.75}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("invalid cell features for hit %d:\ngot = %v\nwant= %v", i, got, want)
+		}
+	}
+	for k := range g.Senders {
+		i, j := g.Senders[k], g.Receivers[k]
+		want := evt.Mcs[i].PID != 0 && evt.Mcs[i].PID == evt.Mcs[j].PID
+		if g.Labels[k] != want {
+			t.Fatalf("invalid label for edge (%d,%d): got=%v, want=%v", i, j, g.Labels[k], want)
+		}
+	}
+
+	// truth is matched to hits by hit ID.
+	rev := evt
+	rev.Mcs = nil
+	for i := len(evt.Mcs) - 1; i >= 0; i-- {
+		rev.Mcs = append(rev.Mcs, evt.Mcs[i])
+	}
+	if got := New(lg).Build(rev).Labels; !reflect.DeepEqual(got, g.Labels) {
+		t.Fatalf("edge labels depend on the order of the truth")
+	}
+
+	st := Evaluate(evt, g)
+	if got, want := st.Segments, 50*(len(testhelix.Radii)-1); got != want {
+		t.Fatalf("invalid number of segments: got=%d, want=%d", got, want)
+	}
+	if st.Efficiency() != 1 {
+		t.Fatalf("invalid efficiency: %v (%+v)", st.Efficiency(), st)
+	}
+	if st.Purity() <= 0 || st.Purity() >= 1 {
+		t.Fatalf("invalid purity: %v (%+v)", st.Purity(), st)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	evt := trackml.Event{
+		Hits: []trackml.Hit{
+			{HitID: 1, X: 10, LayerID: 2},
+			{HitID: 2, X: 20, LayerID: 4},
+			{HitID: 3, X: 21, LayerID: 4},
+			{HitID: 4, X: 30, LayerID: 6},
+			{HitID: 5, X: 10, Y: 1, LayerID: 2},
+		},
+		Ps: []trackml.Particle{{ID: 1}},
+		Mcs: []trackml.Truth{
+			{HitID: 1, PID: 1}, {HitID: 2, PID: 1}, {HitID: 3, PID: 1},
+			{HitID: 4, PID: 1}, {HitID: 5, PID: 0},
+		},
+	}
+	g := Graph{
+		Senders:   []int{0, 0, 2, 4},
+		Receivers: []int{1, 2, 3, 1},
+		Labels:    []bool{true, true, true, false},
+	}
+	st := Evaluate(evt, g)
+	want := Stats{Edges: 4, True: 3, Segments: 4, Found: 3}
+	if st != want {
+		t.Fatalf("invalid stats\ngot = %+v\nwant= %+v", st, want)
+	}
+}
+
+func TestWriteNPZ(t *testing.T) {
+	rnd := rand.New(rand.NewSource(42))
+	evt := testhelix.New(rnd, 5, 10, 2)
+
+	lg := layers.New()
+	lg.Add(evt)
+	g := New(lg).Build(evt)
+
+	buf := new(bytes.Buffer)
+	err := g.WriteNPZ(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := npz.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	var senders []int64
+	err = r.Read("edge_senders.npy", &senders)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(senders) != len(g.Senders) {
+		t.Fatalf("invalid number of edges: got=%d, want=%d", len(senders), len(g.Senders))
+	}
+	for i, v := range senders {
+		if int(v) != g.Senders[i] {
+			t.Fatalf("invalid sender %d: got=%d, want=%d", i, v, g.Senders[i])
+		}
+	}
+
+	var labels []bool
+	err = r.Read("edge_labels.npy", &labels)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(labels, g.Labels) {
+		t.Fatalf("invalid labels\ngot = %v\nwant= %v", labels, g.Labels)
+	}
+
+	var nodes mat.Dense
+	err = r.Read("node_features.npy", &nodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !mat.Equal(&nodes, g.Nodes) {
+		t.Fatalf("invalid node features")
+	}
+}
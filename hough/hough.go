@@ -5,6 +5,8 @@
 package hough
 
 import (
+	"encoding/json"
+	"io"
 	"math"
 	"sort"
 
@@ -72,34 +74,16 @@ func New(hits []trackml.Hit) *Hough {
 	return &hough
 }
 
-func (hough *Hough) Calc(tracks [][]int, theta float64, nbinsR0Inv, nbinsGamma, minHits int) [][]int {
-	if nbinsR0Inv <= 0 {
-		nbinsR0Inv = 200
-	}
-	if nbinsGamma <= 0 {
-		nbinsGamma = 100
-	}
-
-	for i := range hough.ComboDigi {
-		hough.ComboDigi[i] = 0
-		hough.ComboDigiN[i] = 0
-	}
-
-	for i := range hough.HitIDs {
-		rinv := 1 / hough.R[i]
-		r0inv := 2 * math.Cos(hough.Phi[i]-theta) * rinv
-		gamma := hough.Z[i] * rinv
-		hough.R0Inv[i] = r0inv
-		hough.Gamma[i] = gamma
-	}
-
-	digitizeCol(hough.R0InvDigi, hough.R0Inv, nbinsR0Inv, -0.02, +0.02) // Tune it
-	digitizeCol(hough.GammaDigi, hough.Gamma, nbinsGamma, -50.0, +50.0) // Tune it
-	hough.combineDigi(hough.ComboDigi, [][]int{hough.R0InvDigi, hough.GammaDigi})
+// Ranges of the digitized r0inv and gamma columns.
+const (
+	R0InvMin = -0.02
+	R0InvMax = +0.02
+	GammaMin = -50.0
+	GammaMax = +50.0
+)
 
-	hough.countDigi(hough.ComboDigiN)
-	hough.fiducialCut(hough.ComboDigiN, hough.R0InvDigi, nbinsR0Inv)
-	hough.fiducialCut(hough.ComboDigiN, hough.GammaDigi, nbinsGamma)
+func (hough *Hough) Calc(tracks [][]int, theta float64, nbinsR0Inv, nbinsGamma, minHits int) [][]int {
+	hough.fill(theta, nbinsR0Inv, nbinsGamma)
 
 	iset := make(map[int]int, len(hough.ComboDigi)/2)
 	for i, digi := range hough.ComboDigi {
@@ -129,20 +113,47 @@ func (hough *Hough) Calc(tracks [][]int, theta float64, nbinsR0Inv, nbinsGamma,
 	return tracks
 }
 
-// func (h *Hough) Dump(fname string) {
-// 	f, err := os.Create(fname)
-// 	if err != nil {
-// 		log.Fatal(err)
-// 	}
-// 	defer f.Close()
-//
-// 	enc := json.NewEncoder(f)
-// 	enc.SetIndent("", " ")
-// 	err = enc.Encode(h)
-// 	if err = f.Close(); err != nil {
-// 		log.Fatal(err)
-// 	}
-// }
+// fill fills the accumulator for the provided theta slice.
+func (hough *Hough) fill(theta float64, nbinsR0Inv, nbinsGamma int) {
+	if nbinsR0Inv <= 0 {
+		nbinsR0Inv = 200
+	}
+	if nbinsGamma <= 0 {
+		nbinsGamma = 100
+	}
+
+	for i := range hough.ComboDigi {
+		hough.ComboDigi[i] = 0
+		hough.ComboDigiN[i] = 0
+	}
+
+	for i := range hough.HitIDs {
+		rinv := 1 / hough.R[i]
+		r0inv := 2 * math.Cos(hough.Phi[i]-theta) * rinv
+		gamma := hough.Z[i] * rinv
+		hough.R0Inv[i] = r0inv
+		hough.Gamma[i] = gamma
+	}
+
+	digitizeCol(hough.R0InvDigi, hough.R0Inv, nbinsR0Inv, R0InvMin, R0InvMax) // Tune it
+	digitizeCol(hough.GammaDigi, hough.Gamma, nbinsGamma, GammaMin, GammaMax) // Tune it
+	hough.combineDigi(hough.ComboDigi, [][]int{hough.R0InvDigi, hough.GammaDigi})
+
+	hough.countDigi(hough.ComboDigiN)
+	hough.fiducialCut(hough.ComboDigiN, hough.R0InvDigi, nbinsR0Inv)
+	hough.fiducialCut(hough.ComboDigiN, hough.GammaDigi, nbinsGamma)
+}
+
+// Dump writes the columns of the Hough transform to w, in JSON.
+func (h *Hough) Dump(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", " ")
+	err := enc.Encode(h)
+	if err != nil {
+		return errors.Wrapf(err, "hough: could not encode columns")
+	}
+	return nil
+}
 
 func cart2Cyl(xs, ys []float64) (rs, phis []float64) {
 	rs = make([]float64, len(xs))
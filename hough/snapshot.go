@@ -0,0 +1,115 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hough
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// Snapshot is the state of the Hough accumulator for a theta slice.
+//
+// Hits are digitized in NBinsR0Inv bins of r0inv and NBinsGamma bins of
+// gamma.
+// Bin 0 holds hits below the range of a column, and bin NBins the hits
+// above it.
+// Bin k, for 0 < k < NBins, holds the hits in (min+(k-1)*w, min+k*w], with
+// w = (max-min)/(NBins-1).
+type Snapshot struct {
+	Theta      float64 `json:"theta"`
+	NBinsR0Inv int     `json:"nbins_r0inv"`
+	NBinsGamma int     `json:"nbins_gamma"`
+
+	HitIDs    []int     `json:"hit_id"`
+	R0Inv     []float64 `json:"r0inv"`
+	Gamma     []float64 `json:"gamma"`
+	R0InvDigi []int     `json:"r0inv_bin"`
+	GammaDigi []int     `json:"gamma_bin"`
+	Counts    []int     `json:"count"` // number of hits in the bin of each hit, 0 outside of the fiducial range
+}
+
+// Snapshot fills the accumulator for the provided theta slice and returns
+// a copy of its state.
+func (h *Hough) Snapshot(theta float64, nbinsR0Inv, nbinsGamma int) Snapshot {
+	if nbinsR0Inv <= 0 {
+		nbinsR0Inv = 200
+	}
+	if nbinsGamma <= 0 {
+		nbinsGamma = 100
+	}
+	h.fill(theta, nbinsR0Inv, nbinsGamma)
+
+	return Snapshot{
+		Theta:      theta,
+		NBinsR0Inv: nbinsR0Inv,
+		NBinsGamma: nbinsGamma,
+		HitIDs:     append([]int(nil), h.HitIDs...),
+		R0Inv:      append([]float64(nil), h.R0Inv...),
+		Gamma:      append([]float64(nil), h.Gamma...),
+		R0InvDigi:  append([]int(nil), h.R0InvDigi...),
+		GammaDigi:  append([]int(nil), h.GammaDigi...),
+		Counts:     append([]int(nil), h.ComboDigiN...),
+	}
+}
+
+// Bins returns the number of hits in each bin of the accumulator, indexed
+// by r0inv bin then gamma bin.
+func (s Snapshot) Bins() [][]int {
+	bins := make([][]int, s.NBinsR0Inv+1)
+	for i := range bins {
+		bins[i] = make([]int, s.NBinsGamma+1)
+	}
+	for i := range s.HitIDs {
+		bins[s.R0InvDigi[i]][s.GammaDigi[i]]++
+	}
+	return bins
+}
+
+// R0InvBin returns the range of the k-th r0inv bin, 0 < k < NBinsR0Inv.
+func (s Snapshot) R0InvBin(k int) (lo, hi float64) {
+	return binRange(k, s.NBinsR0Inv, R0InvMin, R0InvMax)
+}
+
+// GammaBin returns the range of the k-th gamma bin, 0 < k < NBinsGamma.
+func (s Snapshot) GammaBin(k int) (lo, hi float64) {
+	return binRange(k, s.NBinsGamma, GammaMin, GammaMax)
+}
+
+func binRange(k, nbins int, min, max float64) (lo, hi float64) {
+	w := (max - min) / float64(nbins-1)
+	return min + float64(k-1)*w, min + float64(k)*w
+}
+
+// WriteJSON writes the snapshot to w, in JSON.
+func (s Snapshot) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", " ")
+	err := enc.Encode(s)
+	if err != nil {
+		return errors.Wrapf(err, "hough: could not encode snapshot")
+	}
+	return nil
+}
+
+// WriteCSV writes the snapshot to w, in CSV with a header line and one row
+// per hit.
+func (s Snapshot) WriteCSV(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "hit_id,r0inv,gamma,r0inv_bin,gamma_bin,count\n")
+	for i, id := range s.HitIDs {
+		fmt.Fprintf(bw, "%d,%v,%v,%d,%d,%d\n",
+			id, s.R0Inv[i], s.Gamma[i], s.R0InvDigi[i], s.GammaDigi[i], s.Counts[i],
+		)
+	}
+	err := bw.Flush()
+	if err != nil {
+		return errors.Wrapf(err, "hough: could not write snapshot")
+	}
+	return nil
+}
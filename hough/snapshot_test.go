@@ -0,0 +1,127 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hough
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sbinet/go-trackml"
+)
+
+// newHits returns n hits along a straight line from the origin, in the
+// direction (phi, theta), and a few scattered hits.
+func newHits(n int, phi, cot float64) []trackml.Hit {
+	var hits []trackml.Hit
+	for i := 0; i < n; i++ {
+		r := 50 * float64(i+1)
+		hits = append(hits, trackml.Hit{
+			HitID: i + 1,
+			X:     r * math.Cos(phi),
+			Y:     r * math.Sin(phi),
+			Z:     r * cot,
+		})
+	}
+	for i, xyz := range [][3]float64{{100, 200, 300}, {-300, 50, -20}, {10, -400, 1000}} {
+		hits = append(hits, trackml.Hit{HitID: n + i + 1, X: xyz[0], Y: xyz[1], Z: xyz[2]})
+	}
+	return hits
+}
+
+func TestSnapshot(t *testing.T) {
+	const (
+		nhits      = 10
+		nbinsR0Inv = 200
+		nbinsGamma = 500
+	)
+	hits := newHits(nhits, 1, 0.5)
+	h := New(hits)
+
+	// a straight line from the origin is on the r0inv=0 line of the theta
+	// slice perpendicular to it.
+	s := h.Snapshot(1+math.Pi/2, nbinsR0Inv, nbinsGamma)
+	if got, want := s.Counts[0], nhits; got != want {
+		t.Fatalf("invalid count: got=%d, want=%d", got, want)
+	}
+
+	bins := s.Bins()
+	if got, want := bins[s.R0InvDigi[0]][s.GammaDigi[0]], nhits; got != want {
+		t.Fatalf("invalid bin content: got=%d, want=%d", got, want)
+	}
+	sum := 0
+	for _, row := range bins {
+		for _, n := range row {
+			sum += n
+		}
+	}
+	if sum != len(hits) {
+		t.Fatalf("invalid number of hits in accumulator: got=%d, want=%d", sum, len(hits))
+	}
+
+	for i := range s.HitIDs {
+		if k := s.R0InvDigi[i]; 0 < k && k < s.NBinsR0Inv {
+			lo, hi := s.R0InvBin(k)
+			if v := s.R0Inv[i]; v <= lo || hi < v {
+				t.Fatalf("hit %d: r0inv=%v not in bin %d (%v, %v]", i, v, k, lo, hi)
+			}
+		}
+		if k := s.GammaDigi[i]; 0 < k && k < s.NBinsGamma {
+			lo, hi := s.GammaBin(k)
+			if v := s.Gamma[i]; v <= lo || hi < v {
+				t.Fatalf("hit %d: gamma=%v not in bin %d (%v, %v]", i, v, k, lo, hi)
+			}
+		}
+	}
+
+	// the snapshot is a copy of the accumulator.
+	h.Snapshot(0, nbinsR0Inv, nbinsGamma)
+	if got, want := s.Counts[0], nhits; got != want {
+		t.Fatalf("snapshot modified by accumulator: got=%d, want=%d", got, want)
+	}
+
+	tracks := New(hits).Calc(nil, 1+math.Pi/2, nbinsR0Inv, nbinsGamma, nhits)
+	if want := [][]int{{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}}; !reflect.DeepEqual(tracks, want) {
+		t.Fatalf("invalid tracks\ngot = %v\nwant= %v", tracks, want)
+	}
+}
+
+func TestSnapshotWrite(t *testing.T) {
+	hits := newHits(3, 1, 0.5)
+	s := New(hits).Snapshot(1+math.Pi/2, 200, 500)
+
+	buf := new(bytes.Buffer)
+	err := s.WriteJSON(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Snapshot
+	err = json.Unmarshal(buf.Bytes(), &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, s) {
+		t.Fatalf("JSON round-trip error\ngot = %+v\nwant= %+v", got, s)
+	}
+
+	buf.Reset()
+	err = s.WriteCSV(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if got, want := len(lines), 1+len(hits); got != want {
+		t.Fatalf("invalid number of CSV lines: got=%d, want=%d", got, want)
+	}
+	if got, want := lines[0], "hit_id,r0inv,gamma,r0inv_bin,gamma_bin,count"; got != want {
+		t.Fatalf("invalid CSV header\ngot = %q\nwant= %q", got, want)
+	}
+	if !strings.HasPrefix(lines[1], "1,") || !strings.HasSuffix(lines[1], ",3") {
+		t.Fatalf("invalid CSV row: %q", lines[1])
+	}
+}
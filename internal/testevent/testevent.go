@@ -0,0 +1,99 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package testevent generates the CSV files of synthetic TrackML events, for
+// tests.
+package testevent // import "github.com/sbinet/go-trackml/internal/testevent"
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// PID is the ID of the first particle of the generated events.
+const PID = 4503599627370496
+
+// Event holds the rows of the CSV files of an event, indexed by file kind
+// ("hits", "cells", "particles" and "truth"), with the header line first.
+//
+// Rows may be altered before the files are written, to test invalid events.
+type Event map[string][]string
+
+// New returns an event with nhits hits, 2 cells per hit and size hits per
+// particle, the particle IDs starting at PID.
+// Hit weights are uniform.
+//
+// Floating-point values are drawn from a fixed seed and have the precision of
+// the TrackML files.
+func New(nhits, size int) Event {
+	var (
+		rnd = rand.New(rand.NewSource(1234))
+		evt = Event{
+			"hits":      {"hit_id,x,y,z,volume_id,layer_id,module_id"},
+			"cells":     {"hit_id,ch0,ch1,value"},
+			"particles": {"particle_id,vx,vy,vz,px,py,pz,q,nhits"},
+			"truth":     {"hit_id,particle_id,tx,ty,tz,tpx,tpy,tpz,weight"},
+		}
+	)
+	add := func(name, format string, args ...interface{}) {
+		evt[name] = append(evt[name], fmt.Sprintf(format, args...))
+	}
+
+	for i := 0; i < nhits; i++ {
+		add("hits", "%d,%.4f,%.4f,%.1f,%d,%d,%d",
+			i+1, rnd.NormFloat64()*500, rnd.NormFloat64()*500, rnd.NormFloat64()*1000,
+			7+rnd.Intn(12), 2*(1+rnd.Intn(7)), 1+rnd.Intn(3000),
+		)
+	}
+	for i := 0; i < nhits; i++ {
+		for j := 0; j < 2; j++ {
+			add("cells", "%d,%d,%d,%.6g", i+1, rnd.Intn(1200), rnd.Intn(1200), rnd.Float64())
+		}
+	}
+	for i := 0; i*size < nhits; i++ {
+		n := size
+		if rem := nhits - i*size; rem < n {
+			n = rem
+		}
+		add("particles", "%d,%.6g,%.6g,%.6g,%.6g,%.6g,%.6g,%d,%d",
+			PID+i, rnd.NormFloat64()*0.01, rnd.NormFloat64()*0.01, rnd.NormFloat64()*5,
+			rnd.NormFloat64(), rnd.NormFloat64(), rnd.NormFloat64(), 2*rnd.Intn(2)-1, n,
+		)
+	}
+	for i := 0; i < nhits; i++ {
+		add("truth", "%d,%d,%.6g,%.6g,%.6g,%.6g,%.6g,%.6g,%.6g",
+			i+1, PID+i/size,
+			rnd.NormFloat64()*500, rnd.NormFloat64()*500, rnd.NormFloat64()*1000,
+			rnd.NormFloat64(), rnd.NormFloat64(), rnd.NormFloat64(), 1/float64(nhits),
+		)
+	}
+	return evt
+}
+
+// Files returns the content of the CSV files of the event, indexed by file
+// name, for the provided event ID.
+func (evt Event) Files(evtid string) map[string][]byte {
+	files := make(map[string][]byte, len(evt))
+	for name, rows := range evt {
+		files[evtid+"-"+name+".csv"] = []byte(strings.Join(rows, "\n") + "\n")
+	}
+	return files
+}
+
+// Write writes the CSV files of the event in dir, for the provided event ID,
+// and returns the path prefix of the files.
+func (evt Event) Write(dir, evtid string) (string, error) {
+	for name, raw := range evt.Files(evtid) {
+		err := ioutil.WriteFile(filepath.Join(dir, name), raw, 0644)
+		if err != nil {
+			return "", errors.Wrapf(err, "testevent: could not write %q", name)
+		}
+	}
+	return filepath.Join(dir, evtid), nil
+}
@@ -0,0 +1,63 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package testhelix generates synthetic events with helical tracks in a
+// barrel detector, for tests.
+package testhelix // import "github.com/sbinet/go-trackml/internal/testhelix"
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/sbinet/go-trackml"
+)
+
+// Radii holds the radii of the barrel layers, in mm.
+// Hits of the layer of index l have a LayerID of 2*(l+1) in volume 8.
+var Radii = []float64{32, 72, 116, 172, 260, 360, 500, 660, 820, 1020}
+
+// New generates an event with nparts particles from the beam axis crossing
+// the barrel layers, in a 2 Tesla field, and nnoise random noise hits.
+// Noise hits have a particle ID of 0.
+// Each hit has ncells cells, with values 0.25, 0.5, ...
+func New(rnd *rand.Rand, nparts, nnoise, ncells int) trackml.Event {
+	var evt trackml.Event
+	add := func(pid int, x, y, z float64, lay int) {
+		evt.Hits = append(evt.Hits, trackml.Hit{X: x, Y: y, Z: z, VolumeID: 8, LayerID: 2 * (lay + 1)})
+		evt.Mcs = append(evt.Mcs, trackml.Truth{PID: pid})
+	}
+	for ip := 0; ip < nparts; ip++ {
+		var (
+			pid  = ip + 1
+			q    = float64(2*rnd.Intn(2) - 1)
+			pt   = 1 + 4*rnd.Float64()
+			phi0 = 2 * math.Pi * rnd.Float64()
+			cot  = 2 * (rnd.Float64() - 0.5)
+			vz   = 20 * rnd.NormFloat64()
+			r    = pt / (0.299792458e-3 * 2)
+			cx   = q * r * math.Sin(phi0)
+			cy   = -q * r * math.Cos(phi0)
+			b0   = math.Atan2(-cy, -cx)
+		)
+		evt.Ps = append(evt.Ps, trackml.Particle{ID: pid, Vz: vz})
+		for l, rl := range Radii {
+			s := 2 * r * math.Asin(rl/(2*r))
+			b := b0 - q*s/r
+			add(pid, cx+r*math.Cos(b), cy+r*math.Sin(b), vz+cot*s, l)
+		}
+	}
+	for i := 0; i < nnoise; i++ {
+		l := rnd.Intn(len(Radii))
+		phi := 2 * math.Pi * rnd.Float64()
+		add(0, Radii[l]*math.Cos(phi), Radii[l]*math.Sin(phi), 1000*(rnd.Float64()-0.5), l)
+	}
+	for i := range evt.Hits {
+		evt.Hits[i].HitID = i + 1
+		evt.Mcs[i].HitID = i + 1
+		for k := 0; k < ncells; k++ {
+			evt.Cells = append(evt.Cells, trackml.Cell{HitID: i + 1, Value: 0.25 * float64(k+1)})
+		}
+	}
+	return evt
+}
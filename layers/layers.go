@@ -0,0 +1,258 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package layers describes the connectivity between detector layers,
+// as learned from the trajectories of Monte-Carlo particles.
+package layers // import "github.com/sbinet/go-trackml/layers"
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/sbinet/go-trackml"
+)
+
+// Layer identifies a detector layer.
+type Layer struct {
+	Volume int `json:"volume"`
+	Layer  int `json:"layer"`
+}
+
+// HitLayer returns the layer of the provided hit.
+func HitLayer(hit trackml.Hit) Layer {
+	return Layer{Volume: hit.VolumeID, Layer: hit.LayerID}
+}
+
+// Transition describes how often particles cross layer To right after
+// layer From.
+type Transition struct {
+	From  Layer   `json:"from"`
+	To    Layer   `json:"to"`
+	Count int     `json:"count"` // number of particles crossing From then To
+	Freq  float64 `json:"freq"`  // fraction of the transitions out of From going to To
+}
+
+// Graph is a directed graph of consecutive layers along particle
+// trajectories.
+type Graph struct {
+	counts map[[2]Layer]int
+	totals map[Layer]int
+}
+
+// New returns a new, empty, layer graph.
+func New() *Graph {
+	return &Graph{
+		counts: make(map[[2]Layer]int),
+		totals: make(map[Layer]int),
+	}
+}
+
+// Learn returns the layer graph learned from all the events of the dataset.
+// The dataset must have been opened with Monte-Carlo informations.
+func Learn(ds *trackml.Dataset) (*Graph, error) {
+	g := New()
+	for ds.Next() {
+		evt := ds.Event()
+		g.Add(evt)
+		evt.Delete()
+	}
+	if err := ds.Err(); err != nil {
+		return nil, errors.Wrapf(err, "layers: could not read dataset")
+	}
+	return g, nil
+}
+
+// Add adds the trajectories of the particles of the provided event to the graph.
+//
+// Consecutive hits on the same layer, from overlapping modules, are
+// considered as a single crossing of that layer.
+// Noise hits are ignored.
+func (g *Graph) Add(evt trackml.Event) {
+	for _, traj := range Trajectories(evt) {
+		for i := 1; i < len(traj); i++ {
+			from := HitLayer(evt.Hits[traj[i-1]])
+			to := HitLayer(evt.Hits[traj[i]])
+			if from == to {
+				continue
+			}
+			g.counts[[2]Layer{from, to}]++
+			g.totals[from]++
+		}
+	}
+}
+
+// Trajectories returns the indices of the hits of each particle of the
+// provided event, keyed by particle ID.
+//
+// The hits of each particle are ordered by increasing distance to the
+// production vertex of the particle.
+// Noise hits, and hits without Monte-Carlo truth, are ignored.
+func Trajectories(evt trackml.Event) map[int][]int {
+	vtx := make(map[int][3]float64, len(evt.Ps))
+	for _, p := range evt.Ps {
+		vtx[p.ID] = [3]float64{p.Vx, p.Vy, p.Vz}
+	}
+
+	var (
+		dist  = make([]float64, len(evt.Hits))
+		trajs = make(map[int][]int)
+	)
+	for i, pid := range evt.ParticleIDs() {
+		if pid == 0 {
+			continue
+		}
+		var (
+			v  = vtx[pid]
+			dx = evt.Hits[i].X - v[0]
+			dy = evt.Hits[i].Y - v[1]
+			dz = evt.Hits[i].Z - v[2]
+		)
+		dist[i] = math.Sqrt(dx*dx + dy*dy + dz*dz)
+		trajs[pid] = append(trajs[pid], i)
+	}
+
+	for _, traj := range trajs {
+		sort.Slice(traj, func(i, j int) bool { return dist[traj[i]] < dist[traj[j]] })
+	}
+	return trajs
+}
+
+// Count returns the number of particles crossing layer to right after
+// layer from.
+func (g *Graph) Count(from, to Layer) int {
+	return g.counts[[2]Layer{from, to}]
+}
+
+// Freq returns the fraction of the transitions out of layer from going to
+// layer to.
+func (g *Graph) Freq(from, to Layer) float64 {
+	tot := g.totals[from]
+	if tot == 0 {
+		return 0
+	}
+	return float64(g.counts[[2]Layer{from, to}]) / float64(tot)
+}
+
+// Next returns the transitions out of the provided layer, by decreasing
+// frequency.
+func (g *Graph) Next(from Layer) []Transition {
+	var trs []Transition
+	for k, n := range g.counts {
+		if k[0] != from {
+			continue
+		}
+		trs = append(trs, g.transition(k, n))
+	}
+	sortTransitions(trs)
+	return trs
+}
+
+// Transitions returns all the transitions of the graph, ordered by source
+// layer and decreasing frequency.
+func (g *Graph) Transitions() []Transition {
+	trs := make([]Transition, 0, len(g.counts))
+	for k, n := range g.counts {
+		trs = append(trs, g.transition(k, n))
+	}
+	sortTransitions(trs)
+	return trs
+}
+
+// Prune returns a new graph keeping only the transitions with a frequency of
+// at least minFreq.
+// Frequencies are not renormalized.
+func (g *Graph) Prune(minFreq float64) *Graph {
+	o := New()
+	for k, n := range g.counts {
+		if g.Freq(k[0], k[1]) < minFreq {
+			continue
+		}
+		o.counts[k] = n
+	}
+	for k, n := range g.totals {
+		o.totals[k] = n
+	}
+	return o
+}
+
+func (g *Graph) transition(k [2]Layer, n int) Transition {
+	return Transition{
+		From:  k[0],
+		To:    k[1],
+		Count: n,
+		Freq:  float64(n) / float64(g.totals[k[0]]),
+	}
+}
+
+func sortTransitions(trs []Transition) {
+	sort.Slice(trs, func(i, j int) bool {
+		a, b := trs[i], trs[j]
+		switch {
+		case a.From.Volume != b.From.Volume:
+			return a.From.Volume < b.From.Volume
+		case a.From.Layer != b.From.Layer:
+			return a.From.Layer < b.From.Layer
+		case a.Count != b.Count:
+			return a.Count > b.Count
+		case a.To.Volume != b.To.Volume:
+			return a.To.Volume < b.To.Volume
+		}
+		return a.To.Layer < b.To.Layer
+	})
+}
+
+type jsonGraph struct {
+	Totals      []jsonTotal  `json:"totals"`
+	Transitions []Transition `json:"transitions"`
+}
+
+type jsonTotal struct {
+	Layer Layer `json:"layer"`
+	Count int   `json:"count"`
+}
+
+// Save writes the graph to w, in JSON.
+func (g *Graph) Save(w io.Writer) error {
+	raw := jsonGraph{
+		Transitions: g.Transitions(),
+	}
+	for k, n := range g.totals {
+		raw.Totals = append(raw.Totals, jsonTotal{Layer: k, Count: n})
+	}
+	sort.Slice(raw.Totals, func(i, j int) bool {
+		a, b := raw.Totals[i].Layer, raw.Totals[j].Layer
+		if a.Volume != b.Volume {
+			return a.Volume < b.Volume
+		}
+		return a.Layer < b.Layer
+	})
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", " ")
+	err := enc.Encode(raw)
+	if err != nil {
+		return errors.Wrapf(err, "layers: could not encode graph")
+	}
+	return nil
+}
+
+// Load reads a graph, previously written with Save, from r.
+func Load(r io.Reader) (*Graph, error) {
+	var raw jsonGraph
+	err := json.NewDecoder(r).Decode(&raw)
+	if err != nil {
+		return nil, errors.Wrapf(err, "layers: could not decode graph")
+	}
+	g := New()
+	for _, tot := range raw.Totals {
+		g.totals[tot.Layer] = tot.Count
+	}
+	for _, tr := range raw.Transitions {
+		g.counts[[2]Layer{tr.From, tr.To}] = tr.Count
+	}
+	return g, nil
+}
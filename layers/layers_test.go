@@ -0,0 +1,129 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package layers
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/sbinet/go-trackml"
+)
+
+func newTestEvent() trackml.Event {
+	var evt trackml.Event
+	add := func(pid int, x, y, z float64, vol, lay int) {
+		id := len(evt.Hits) + 1
+		evt.Hits = append(evt.Hits, trackml.Hit{HitID: id, X: x, Y: y, Z: z, VolumeID: vol, LayerID: lay})
+		evt.Mcs = append(evt.Mcs, trackml.Truth{HitID: id, PID: pid})
+	}
+	evt.Ps = []trackml.Particle{{ID: 1}, {ID: 2}, {ID: 3, Vz: 500}}
+
+	// hits are not ordered along the trajectories.
+	add(1, 70, 0, 0, 8, 4)
+	add(1, 30, 0, 0, 8, 2)
+	add(2, 0, 30, 10, 8, 2)
+	add(0, 0, 50, 10, 8, 2)
+	add(1, 110, 0, 0, 8, 6)
+	add(2, 0, 72, 20, 8, 4)
+	add(2, 0, 70, 20, 8, 4)
+	add(2, 0, 100, 800, 13, 2)
+	add(3, 30, 0, 510, 8, 2) // vertex at z=500
+	add(3, 30, 0, 400, 8, 4)
+	return evt
+}
+
+func TestTrajectories(t *testing.T) {
+	want := map[int][]int{
+		1: {1, 0, 4},
+		2: {2, 6, 5, 7},
+		3: {8, 9},
+	}
+	evt := newTestEvent()
+	if got := Trajectories(evt); !reflect.DeepEqual(got, want) {
+		t.Fatalf("invalid trajectories\ngot = %v\nwant= %v", got, want)
+	}
+
+	// truth is matched to hits by hit ID: reverse the truth, drop the truth
+	// of the first hit and add truth for unknown hits.
+	var mcs []trackml.Truth
+	for i := len(evt.Mcs) - 1; i > 0; i-- {
+		mcs = append(mcs, evt.Mcs[i])
+	}
+	evt.Mcs = append(mcs, trackml.Truth{HitID: 42, PID: 1}, trackml.Truth{HitID: 43, PID: 2})
+	want[1] = []int{1, 4}
+	if got := Trajectories(evt); !reflect.DeepEqual(got, want) {
+		t.Fatalf("invalid trajectories\ngot = %v\nwant= %v", got, want)
+	}
+}
+
+func TestGraph(t *testing.T) {
+	var (
+		l82  = Layer{8, 2}
+		l84  = Layer{8, 4}
+		l86  = Layer{8, 6}
+		l132 = Layer{13, 2}
+	)
+
+	g := New()
+	g.Add(newTestEvent())
+
+	for _, tc := range []struct {
+		from, to Layer
+		count    int
+		freq     float64
+	}{
+		{l82, l84, 3, 1},
+		{l84, l86, 1, 0.5},
+		{l84, l132, 1, 0.5},
+		{l84, l84, 0, 0},
+		{l86, l84, 0, 0},
+	} {
+		if got := g.Count(tc.from, tc.to); got != tc.count {
+			t.Errorf("invalid count %v->%v: got=%d, want=%d", tc.from, tc.to, got, tc.count)
+		}
+		if got := g.Freq(tc.from, tc.to); got != tc.freq {
+			t.Errorf("invalid freq %v->%v: got=%v, want=%v", tc.from, tc.to, got, tc.freq)
+		}
+	}
+
+	want := []Transition{
+		{From: l84, To: l86, Count: 1, Freq: 0.5},
+		{From: l84, To: l132, Count: 1, Freq: 0.5},
+	}
+	if got := g.Next(l84); !reflect.DeepEqual(got, want) {
+		t.Fatalf("invalid transitions\ngot = %v\nwant= %v", got, want)
+	}
+
+	g.Add(newTestEvent())
+	g.counts[[2]Layer{l84, l86}]++
+	g.totals[l84]++
+	pruned := g.Prune(0.55)
+	if got, want := len(pruned.Transitions()), 2; got != want {
+		t.Fatalf("invalid number of pruned transitions: got=%d, want=%d", got, want)
+	}
+	if got := pruned.Count(l84, l132); got != 0 {
+		t.Fatalf("transition not pruned")
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	g := New()
+	g.Add(newTestEvent())
+
+	buf := new(bytes.Buffer)
+	err := g.Save(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Load(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, g) {
+		t.Fatalf("round-trip failed\ngot = %v\nwant= %v", got, g)
+	}
+}
@@ -0,0 +1,228 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package nn implements the inference of small multi-layer perceptrons,
+// trained offline.
+//
+// Models are loaded from a JSON document listing their layers, in order:
+//
+//	{
+//	  "layers": [
+//	    {"type": "dense", "weights": [[...], ...], "bias": [...]},
+//	    {"type": "batchnorm", "mean": [...], "var": [...], "gamma": [...], "beta": [...], "eps": 1e-5},
+//	    {"type": "relu"},
+//	    {"type": "dense", "weights": [[...], ...], "bias": [...]},
+//	    {"type": "sigmoid"}
+//	  ]
+//	}
+//
+// The weights of a dense layer are given as an (inputs, outputs) matrix, as
+// stored by Keras.
+// PyTorch weights of a Linear layer must be transposed.
+package nn // import "github.com/sbinet/go-trackml/nn"
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+
+	"github.com/pkg/errors"
+	"gonum.org/v1/gonum/mat"
+)
+
+// Layer is a layer of a model.
+type Layer interface {
+	// Forward returns the output of the layer for the input x.
+	Forward(x []float64) []float64
+}
+
+// Dense is a fully connected layer: y = x·W + b.
+type Dense struct {
+	W *mat.Dense // weights, (inputs, outputs)
+	B []float64  // biases, one per output
+}
+
+// Forward implements Layer.
+func (l *Dense) Forward(x []float64) []float64 {
+	nin, nout := l.W.Dims()
+	y := make([]float64, nout)
+	for j := 0; j < nout; j++ {
+		v := l.B[j]
+		for i := 0; i < nin; i++ {
+			v += x[i] * l.W.At(i, j)
+		}
+		y[j] = v
+	}
+	return y
+}
+
+// BatchNorm is a batch normalization layer, in inference mode:
+//
+//	y = (x - Mean) / sqrt(Var + Eps) * Gamma + Beta
+type BatchNorm struct {
+	Mean  []float64
+	Var   []float64
+	Gamma []float64
+	Beta  []float64
+	Eps   float64
+}
+
+// Forward implements Layer.
+func (l *BatchNorm) Forward(x []float64) []float64 {
+	y := make([]float64, len(x))
+	for i, v := range x {
+		y[i] = (v-l.Mean[i])/math.Sqrt(l.Var[i]+l.Eps)*l.Gamma[i] + l.Beta[i]
+	}
+	return y
+}
+
+// ReLU is a rectified linear unit activation layer.
+type ReLU struct{}
+
+// Forward implements Layer.
+func (ReLU) Forward(x []float64) []float64 {
+	y := make([]float64, len(x))
+	for i, v := range x {
+		if v > 0 {
+			y[i] = v
+		}
+	}
+	return y
+}
+
+// Sigmoid is a logistic activation layer.
+type Sigmoid struct{}
+
+// Forward implements Layer.
+func (Sigmoid) Forward(x []float64) []float64 {
+	y := make([]float64, len(x))
+	for i, v := range x {
+		y[i] = 1 / (1 + math.Exp(-v))
+	}
+	return y
+}
+
+// Model is a sequence of layers.
+type Model struct {
+	Layers []Layer
+	Inputs int // number of inputs of the model
+}
+
+// Eval returns the output of the model for the input x.
+func (m *Model) Eval(x []float64) []float64 {
+	for _, l := range m.Layers {
+		x = l.Forward(x)
+	}
+	return x
+}
+
+// Predict returns the outputs of the model for each row of x.
+func (m *Model) Predict(x *mat.Dense) *mat.Dense {
+	var (
+		nrows, _ = x.Dims()
+		o        *mat.Dense
+	)
+	for i := 0; i < nrows; i++ {
+		y := m.Eval(x.RawRowView(i))
+		if o == nil {
+			o = mat.NewDense(nrows, len(y), nil)
+		}
+		o.SetRow(i, y)
+	}
+	return o
+}
+
+type jsonModel struct {
+	Layers []jsonLayer `json:"layers"`
+}
+
+type jsonLayer struct {
+	Type    string      `json:"type"`
+	Weights [][]float64 `json:"weights,omitempty"`
+	Bias    []float64   `json:"bias,omitempty"`
+	Mean    []float64   `json:"mean,omitempty"`
+	Var     []float64   `json:"var,omitempty"`
+	Gamma   []float64   `json:"gamma,omitempty"`
+	Beta    []float64   `json:"beta,omitempty"`
+	Eps     float64     `json:"eps,omitempty"`
+}
+
+// Load loads a model from its JSON description.
+func Load(r io.Reader) (*Model, error) {
+	var raw jsonModel
+	err := json.NewDecoder(r).Decode(&raw)
+	if err != nil {
+		return nil, errors.Wrapf(err, "nn: could not decode model")
+	}
+
+	var (
+		m     = &Model{Inputs: -1}
+		width = -1 // number of outputs of the previous layer
+	)
+	check := func(i, n int) error {
+		switch {
+		case width < 0:
+			m.Inputs = n
+		case width != n:
+			return errors.Errorf("nn: layer %d: invalid number of inputs %d, want %d", i, n, width)
+		}
+		return nil
+	}
+
+	for i, l := range raw.Layers {
+		switch l.Type {
+		case "dense":
+			if len(l.Weights) == 0 || len(l.Weights[0]) == 0 {
+				return nil, errors.Errorf("nn: layer %d: empty weights", i)
+			}
+			nin, nout := len(l.Weights), len(l.Weights[0])
+			if len(l.Bias) != nout {
+				return nil, errors.Errorf("nn: layer %d: invalid number of biases %d, want %d", i, len(l.Bias), nout)
+			}
+			w := mat.NewDense(nin, nout, nil)
+			for k, row := range l.Weights {
+				if len(row) != nout {
+					return nil, errors.Errorf("nn: layer %d: invalid weights row %d", i, k)
+				}
+				w.SetRow(k, row)
+			}
+			if err := check(i, nin); err != nil {
+				return nil, err
+			}
+			m.Layers = append(m.Layers, &Dense{W: w, B: l.Bias})
+			width = nout
+
+		case "batchnorm":
+			n := len(l.Mean)
+			if len(l.Var) != n || len(l.Gamma) != n || len(l.Beta) != n {
+				return nil, errors.Errorf("nn: layer %d: inconsistent batch normalization parameters", i)
+			}
+			if err := check(i, n); err != nil {
+				return nil, err
+			}
+			m.Layers = append(m.Layers, &BatchNorm{
+				Mean:  l.Mean,
+				Var:   l.Var,
+				Gamma: l.Gamma,
+				Beta:  l.Beta,
+				Eps:   l.Eps,
+			})
+			width = n
+
+		case "relu":
+			m.Layers = append(m.Layers, ReLU{})
+
+		case "sigmoid":
+			m.Layers = append(m.Layers, Sigmoid{})
+
+		default:
+			return nil, errors.Errorf("nn: layer %d: unknown layer type %q", i, l.Type)
+		}
+	}
+
+	if m.Inputs < 0 {
+		return nil, errors.Errorf("nn: model without dense nor batch normalization layer")
+	}
+	return m, nil
+}
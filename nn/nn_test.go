@@ -0,0 +1,133 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nn
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestLayers(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		l    Layer
+		x    []float64
+		want []float64
+	}{
+		{
+			name: "dense",
+			l: &Dense{
+				W: mat.NewDense(2, 3, []float64{1, 2, 3, 4, 5, 6}),
+				B: []float64{0.5, -0.5, 1},
+			},
+			x:    []float64{1, -1},
+			want: []float64{-2.5, -3.5, -2},
+		},
+		{
+			name: "batchnorm",
+			l: &BatchNorm{
+				Mean:  []float64{1, -1},
+				Var:   []float64{4, 0.25},
+				Gamma: []float64{2, 1},
+				Beta:  []float64{0, 0.5},
+			},
+			x:    []float64{3, 0},
+			want: []float64{2, 2.5},
+		},
+		{
+			name: "relu",
+			l:    ReLU{},
+			x:    []float64{-1, 0, 2},
+			want: []float64{0, 0, 2},
+		},
+		{
+			name: "sigmoid",
+			l:    Sigmoid{},
+			x:    []float64{0},
+			want: []float64{0.5},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := tc.l.Forward(tc.x)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("invalid output\ngot = %v\nwant= %v", got, tc.want)
+			}
+		})
+	}
+}
+
+// TestReference checks the model against outputs computed independently,
+// with the same order of floating point operations.
+func TestReference(t *testing.T) {
+	f, err := os.Open("testdata/mlp.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	m, err := Load(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := m.Inputs, 6; got != want {
+		t.Fatalf("invalid number of inputs: got=%d, want=%d", got, want)
+	}
+
+	raw, err := ioutil.ReadFile("testdata/mlp-ref.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ref struct {
+		Inputs  [][]float64 `json:"inputs"`
+		Outputs [][]float64 `json:"outputs"`
+	}
+	err = json.Unmarshal(raw, &ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	x := mat.NewDense(len(ref.Inputs), m.Inputs, nil)
+	for i, row := range ref.Inputs {
+		x.SetRow(i, row)
+	}
+	y := m.Predict(x)
+	for i, want := range ref.Outputs {
+		got := mat.Row(nil, i, y)
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("invalid output for input %d\ngot = %v\nwant= %v", i, got, want)
+		}
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		json string
+	}{
+		{"invalid-json", `{"layers": [`},
+		{"no-inputs", `{"layers": [{"type": "relu"}]}`},
+		{"unknown-layer", `{"layers": [{"type": "conv2d"}]}`},
+		{"bias", `{"layers": [{"type": "dense", "weights": [[1, 2]], "bias": [0]}]}`},
+		{"ragged", `{"layers": [{"type": "dense", "weights": [[1, 2], [3]], "bias": [0, 0]}]}`},
+		{"mismatch", `{"layers": [
+			{"type": "dense", "weights": [[1, 2]], "bias": [0, 0]},
+			{"type": "dense", "weights": [[1], [2], [3]], "bias": [0]}
+		]}`},
+		{"batchnorm", `{"layers": [{"type": "batchnorm", "mean": [0, 0], "var": [1], "gamma": [1, 1], "beta": [0, 0]}]}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Load(strings.NewReader(tc.json))
+			if err == nil {
+				t.Fatalf("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package pairs generates samples of hit pairs, suitable for training pair
+// classifiers.
+//
+// Candidate pairs are the doublets of hits on consecutive layers passing the
+// geometric cuts of a seeding.Seeder.
+// Positive pairs are made of hits from the same particle, negative pairs of
+// hits from different particles or noise.
+package pairs // import "github.com/sbinet/go-trackml/pairs"
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/sbinet/go-trackml"
+	"github.com/sbinet/go-trackml/features"
+	"github.com/sbinet/go-trackml/layers"
+	"github.com/sbinet/go-trackml/seeding"
+)
+
+// Features are the names of the features of a pair, in the order of
+// Pair.Features.
+var Features = []string{"dphi", "dz", "dr", "deta", "z0", "cos1", "cos2"}
+
+// Pair is a labeled hit pair.
+type Pair struct {
+	EventID  int
+	HitID1   int // hit ID of the inner hit
+	HitID2   int // hit ID of the outer hit
+	Features []float64
+	Label    bool // whether both hits belong to the same particle
+}
+
+// Sampler generates hit pairs from events.
+type Sampler struct {
+	Seeder   *seeding.Seeder   // candidate pairs generator
+	Detector *trackml.Detector // detector geometry, for the cell direction features (may be nil)
+	NegRatio float64           // number of negative pairs per positive pair
+	Rand     *rand.Rand
+}
+
+// New returns a sampler generating balanced samples of pairs of hits on the
+// layers of the provided layer graph.
+func New(g *layers.Graph, det *trackml.Detector, seed int64) *Sampler {
+	return &Sampler{
+		Seeder:   seeding.New(g),
+		Detector: det,
+		NegRatio: 1,
+		Rand:     rand.New(rand.NewSource(seed)),
+	}
+}
+
+// Sample returns all the positive pairs of the provided event, and a random
+// selection of NegRatio times as many negative pairs.
+// The event must hold Monte-Carlo truth, matched to the hits by hit ID.
+func (s *Sampler) Sample(evt trackml.Event) []Pair {
+	var (
+		doublets = s.Seeder.Doublets(evt.Hits)
+		pids     = evt.ParticleIDs()
+		pos      []seeding.Seed
+		neg      []seeding.Seed
+	)
+	for _, d := range doublets {
+		pid := pids[d.Hits[0]]
+		switch {
+		case pid != 0 && pid == pids[d.Hits[1]]:
+			pos = append(pos, d)
+		default:
+			neg = append(neg, d)
+		}
+	}
+
+	nneg := int(math.Round(s.NegRatio * float64(len(pos))))
+	if nneg < len(neg) {
+		s.Rand.Shuffle(len(neg), func(i, j int) { neg[i], neg[j] = neg[j], neg[i] })
+		neg = neg[:nneg]
+	}
+
+	var (
+		ctx   = features.NewContext(evt, s.Detector)
+		dirs  = make(map[int][3]float64)
+		pairs = make([]Pair, 0, len(pos)+len(neg))
+	)
+	dir := func(i int) [3]float64 {
+		d, ok := dirs[i]
+		if !ok {
+			d = cellDir(ctx, i)
+			dirs[i] = d
+		}
+		return d
+	}
+	for _, v := range []struct {
+		seeds []seeding.Seed
+		label bool
+	}{
+		{pos, true},
+		{neg, false},
+	} {
+		for _, d := range v.seeds {
+			i, j := d.Hits[0], d.Hits[1]
+			pairs = append(pairs, Pair{
+				EventID:  evt.ID,
+				HitID1:   evt.Hits[i].HitID,
+				HitID2:   evt.Hits[j].HitID,
+				Features: pairFeatures(evt.Hits[i], evt.Hits[j], dir(i), dir(j)),
+				Label:    v.label,
+			})
+		}
+	}
+	s.Rand.Shuffle(len(pairs), func(i, j int) { pairs[i], pairs[j] = pairs[j], pairs[i] })
+	return pairs
+}
+
+// pairFeatures returns the features of the pair of hits h1 and h2, with
+// estimated track directions d1 and d2.
+func pairFeatures(h1, h2 trackml.Hit, d1, d2 [3]float64) []float64 {
+	var (
+		r1   = math.Hypot(h1.X, h1.Y)
+		r2   = math.Hypot(h2.X, h2.Y)
+		dr   = r2 - r1
+		dz   = h2.Z - h1.Z
+		dphi = math.Remainder(math.Atan2(h2.Y, h2.X)-math.Atan2(h1.Y, h1.X), 2*math.Pi)
+		deta = math.Asinh(h2.Z/r2) - math.Asinh(h1.Z/r1)
+		z0   = math.NaN()
+		seg  = [3]float64{h2.X - h1.X, h2.Y - h1.Y, h2.Z - h1.Z}
+	)
+	if dr != 0 {
+		z0 = h1.Z - r1*dz/dr
+	}
+	return []float64{dphi, dz, dr, deta, z0, compat(d1, seg), compat(d2, seg)}
+}
+
+// cellDir returns the unit direction, in global coordinates, of a track
+// crossing the module of the i-th hit, as estimated from the extent of its
+// cells and the thickness of the module.
+// cellDir returns NaNs if the module of the hit is unknown.
+func cellDir(ctx *features.Context, i int) [3]float64 {
+	nan := math.NaN()
+	m, ok := ctx.Module(i)
+	if !ok {
+		return [3]float64{nan, nan, nan}
+	}
+
+	cells := ctx.Cells(i)
+	if len(cells) == 0 {
+		return [3]float64{nan, nan, nan}
+	}
+	var (
+		nu, nv = features.Extent(cells)
+		mu, mv float64
+	)
+	for _, cell := range cells {
+		mu += float64(cell.Ch0)
+		mv += float64(cell.Ch1)
+	}
+	mu /= float64(len(cells))
+	mv /= float64(len(cells))
+
+	// the sign of the u-v correlation of the cells gives the relative sign
+	// of the u and v components of the direction.
+	cov := 0.0
+	for _, cell := range cells {
+		cov += (float64(cell.Ch0) - mu) * (float64(cell.Ch1) - mv)
+	}
+	var (
+		du = float64(nu) * m.PitchU
+		dv = float64(nv) * m.PitchV
+		dw = 2 * m.HalfT
+	)
+	if cov < 0 {
+		dv = -dv
+	}
+
+	var (
+		u = m.U()
+		v = m.V()
+		w = m.W()
+		d [3]float64
+	)
+	for k := range d {
+		d[k] = du*u[k] + dv*v[k] + dw*w[k]
+	}
+	return unit(d)
+}
+
+// compat returns the absolute value of the cosine of the angle between the
+// unit direction d and the segment seg.
+func compat(d, seg [3]float64) float64 {
+	s := unit(seg)
+	return math.Abs(d[0]*s[0] + d[1]*s[1] + d[2]*s[2])
+}
+
+func unit(v [3]float64) [3]float64 {
+	n := math.Sqrt(v[0]*v[0] + v[1]*v[1] + v[2]*v[2])
+	if n == 0 {
+		return v
+	}
+	return [3]float64{v[0] / n, v[1] / n, v[2] / n}
+}
@@ -0,0 +1,149 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pairs
+
+import (
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sbinet/go-trackml"
+	"github.com/sbinet/go-trackml/features"
+	"github.com/sbinet/go-trackml/internal/testhelix"
+	"github.com/sbinet/go-trackml/layers"
+)
+
+func TestSample(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1234))
+	evt := testhelix.New(rnd, 50, 2000, 0)
+	evt.ID = 42
+
+	g := layers.New()
+	g.Add(evt)
+
+	pid := make(map[int]int, len(evt.Mcs))
+	for _, mc := range evt.Mcs {
+		pid[mc.HitID] = mc.PID
+	}
+
+	// truth is matched to hits by hit ID.
+	for i, j := 0, len(evt.Mcs)-1; i < j; i, j = i+1, j-1 {
+		evt.Mcs[i], evt.Mcs[j] = evt.Mcs[j], evt.Mcs[i]
+	}
+
+	for _, ratio := range []float64{1, 2} {
+		s := New(g, nil, 1)
+		s.NegRatio = ratio
+		pairs := s.Sample(evt)
+
+		npos := 0
+		for _, p := range pairs {
+			if p.EventID != evt.ID {
+				t.Fatalf("invalid event ID: %d", p.EventID)
+			}
+			if len(p.Features) != len(Features) {
+				t.Fatalf("invalid number of features: %d", len(p.Features))
+			}
+			want := pid[p.HitID1] != 0 && pid[p.HitID1] == pid[p.HitID2]
+			if p.Label != want {
+				t.Fatalf("invalid label for pair (%d,%d): got=%v, want=%v", p.HitID1, p.HitID2, p.Label, want)
+			}
+			if p.Label {
+				npos++
+			}
+		}
+		if got, want := npos, 50*(len(testhelix.Radii)-1); got != want {
+			t.Fatalf("invalid number of positive pairs: got=%d, want=%d", got, want)
+		}
+		if got, want := len(pairs)-npos, int(ratio)*npos; got != want {
+			t.Fatalf("invalid number of negative pairs: got=%d, want=%d", got, want)
+		}
+	}
+}
+
+func TestCellDir(t *testing.T) {
+	evt := trackml.Event{
+		Hits: []trackml.Hit{
+			{HitID: 1, X: 100, VolumeID: 8, LayerID: 2, ModuleID: 1},
+			{HitID: 2, X: 200, VolumeID: 8, LayerID: 4, ModuleID: 1},
+		},
+		Cells: []trackml.Cell{
+			{HitID: 1, Ch0: 1, Ch1: 1, Value: 1},
+		},
+	}
+	det := trackml.NewDetector([]trackml.Module{{
+		VolumeID: 8, LayerID: 2, ModuleID: 1,
+		RotYU: 1, RotZV: 1, RotXW: 1,
+		HalfT: 0.15, PitchU: 0.05, PitchV: 0.05,
+	}})
+	ctx := features.NewContext(evt, det)
+
+	d1 := cellDir(ctx, 0)
+	d2 := cellDir(ctx, 1)
+	if !math.IsNaN(d2[0]) {
+		t.Fatalf("expected NaN direction for unknown module: %v", d2)
+	}
+
+	f := pairFeatures(evt.Hits[0], evt.Hits[1], d1, d2)
+	want := []float64{0, 0, 100, 0, 0, 0.3 / math.Sqrt(0.3*0.3+2*0.05*0.05)}
+	for i, v := range want {
+		if math.Abs(f[i]-v) > 1e-12 {
+			t.Fatalf("invalid feature %s: got=%v, want=%v", Features[i], f[i], v)
+		}
+	}
+	if !math.IsNaN(f[6]) {
+		t.Fatalf("invalid feature %s: got=%v, want=NaN", Features[6], f[6])
+	}
+}
+
+func TestWriter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "trackml-pairs-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	_, err = NewWriter(dir, "pairs", 0)
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+
+	w, err := NewWriter(dir, "pairs", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		err = w.Write([]Pair{{
+			EventID:  1,
+			HitID1:   i,
+			HitID2:   i + 1,
+			Features: make([]float64, len(Features)),
+			Label:    i%2 == 0,
+		}})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := w.Chunks(), 3; got != want {
+		t.Fatalf("invalid number of chunks: got=%d, want=%d", got, want)
+	}
+
+	got, err := ioutil.ReadFile(filepath.Join(dir, "pairs-0002.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "event_id,hit_id_1,hit_id_2,dphi,dz,dr,deta,z0,cos1,cos2,label\n1,4,5,0,0,0,0,0,0,0,1\n"
+	if string(got) != want {
+		t.Fatalf("invalid chunk content\ngot = %q\nwant= %q", got, want)
+	}
+}
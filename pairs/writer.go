@@ -0,0 +1,130 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pairs
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Writer streams pairs to a sequence of CSV files, each holding at most a
+// fixed number of pairs.
+//
+// Files are named <prefix>-<chunk>.csv, and have a header line with the
+// columns event_id, hit_id_1, hit_id_2, the pair features and label.
+type Writer struct {
+	dir    string
+	prefix string
+	size   int
+
+	chunk int // index of the current chunk
+	n     int // number of pairs in the current chunk
+	f     *os.File
+	w     *bufio.Writer
+	row   []string
+}
+
+// NewWriter returns a writer creating files in dir, holding at most size
+// pairs each.
+func NewWriter(dir, prefix string, size int) (*Writer, error) {
+	if size <= 0 {
+		return nil, errors.Errorf("pairs: invalid chunk size %d", size)
+	}
+	return &Writer{
+		dir:    dir,
+		prefix: prefix,
+		size:   size,
+		chunk:  -1,
+	}, nil
+}
+
+// Write writes the provided pairs.
+func (w *Writer) Write(pairs []Pair) error {
+	for _, p := range pairs {
+		if w.f == nil || w.n == w.size {
+			err := w.next()
+			if err != nil {
+				return err
+			}
+		}
+
+		label := "0"
+		if p.Label {
+			label = "1"
+		}
+		w.row = append(w.row[:0],
+			strconv.Itoa(p.EventID),
+			strconv.Itoa(p.HitID1),
+			strconv.Itoa(p.HitID2),
+		)
+		for _, v := range p.Features {
+			w.row = append(w.row, strconv.FormatFloat(v, 'g', -1, 64))
+		}
+		w.row = append(w.row, label)
+		_, err := w.w.WriteString(strings.Join(w.row, ",") + "\n")
+		if err != nil {
+			return errors.Wrapf(err, "pairs: could not write pair")
+		}
+		w.n++
+	}
+	return nil
+}
+
+// next closes the current chunk and opens the next one.
+func (w *Writer) next() error {
+	err := w.Close()
+	if err != nil {
+		return err
+	}
+
+	w.chunk++
+	w.n = 0
+	fname := filepath.Join(w.dir, fmt.Sprintf("%s-%04d.csv", w.prefix, w.chunk))
+	w.f, err = os.Create(fname)
+	if err != nil {
+		return errors.Wrapf(err, "pairs: could not create chunk file")
+	}
+	w.w = bufio.NewWriter(w.f)
+	_, err = w.w.WriteString(
+		"event_id,hit_id_1,hit_id_2," + strings.Join(Features, ",") + ",label\n",
+	)
+	if err != nil {
+		return errors.Wrapf(err, "pairs: could not write header")
+	}
+	return nil
+}
+
+// Chunks returns the number of files created so far.
+func (w *Writer) Chunks() int {
+	return w.chunk + 1
+}
+
+// Close flushes and closes the current chunk file.
+func (w *Writer) Close() error {
+	if w.f == nil {
+		return nil
+	}
+	defer func() {
+		w.f = nil
+		w.w = nil
+	}()
+
+	err := w.w.Flush()
+	if err != nil {
+		w.f.Close()
+		return errors.Wrapf(err, "pairs: could not flush chunk file")
+	}
+	err = w.f.Close()
+	if err != nil {
+		return errors.Wrapf(err, "pairs: could not close chunk file")
+	}
+	return nil
+}
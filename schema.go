@@ -0,0 +1,124 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package trackml
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Schemas holds the expected CSV columns of the files of an event, indexed
+// by file suffix.
+var Schemas = map[string][]string{
+	"hits":      csvColumns(Hit{}),
+	"cells":     csvColumns(Cell{}),
+	"particles": csvColumns(Particle{}),
+	"truth":     csvColumns(Truth{}),
+}
+
+// schemaFiles lists the files of an event.
+// Monte-Carlo files are optional.
+var schemaFiles = []struct {
+	name string
+	mc   bool
+}{
+	{"hits", false},
+	{"cells", false},
+	{"particles", true},
+	{"truth", true},
+}
+
+// CheckSchema checks the CSV header of each file of the event against
+// Schemas.
+// The particles and truth files are only checked if they exist.
+func CheckSchema(path, evtid string) error {
+	hdrs, err := ReadHeaders(path, evtid)
+	if err != nil {
+		return err
+	}
+
+	var msgs []string
+	for _, file := range schemaFiles {
+		cols, ok := hdrs[file.name]
+		if !ok {
+			if !file.mc {
+				msgs = append(msgs, fmt.Sprintf("missing %s file", file.name))
+			}
+			continue
+		}
+
+		want := Schemas[file.name]
+		if strings.Join(cols, ",") != strings.Join(want, ",") {
+			msgs = append(msgs, fmt.Sprintf(
+				"invalid %s columns [%s], want [%s]",
+				file.name, strings.Join(cols, ","), strings.Join(want, ","),
+			))
+		}
+	}
+
+	if len(msgs) > 0 {
+		return errors.New(strings.Join(msgs, "; "))
+	}
+	return nil
+}
+
+// ReadHeaders returns the CSV columns of the files of an event, indexed by
+// file suffix as in Schemas.
+// Missing files are not in the returned map.
+func ReadHeaders(path, evtid string) (map[string][]string, error) {
+	return ReadHeadersFS(osFS{}, path, evtid)
+}
+
+// ReadHeadersFS is like ReadHeaders but reads the event from the named
+// directory or archive of fsys.
+func ReadHeadersFS(fsys fs.FS, name, evtid string) (map[string][]string, error) {
+	ds, err := openDataset(fsys, name, evtid)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not open resource %q", name)
+	}
+	defer ds.Close()
+
+	hdrs := make(map[string][]string, len(schemaFiles))
+	for _, file := range schemaFiles {
+		fname := path.Join(ds.dir, evtid+"-"+file.name+".csv")
+		cols, err := readHeader(ds.fsys, fname)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, errors.Wrapf(err, "could not read %s header", file.name)
+		}
+		hdrs[file.name] = cols
+	}
+	return hdrs, nil
+}
+
+// readHeader returns the columns of the header line of a CSV file of fsys.
+func readHeader(fsys fs.FS, fname string) ([]string, error) {
+	f, err := openFile(fsys, fname)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	if !sc.Scan() {
+		if err := sc.Err(); err != nil {
+			return nil, errors.Wrapf(err, "could not read header")
+		}
+		return nil, errors.Errorf("empty file")
+	}
+
+	cols := strings.Split(sc.Text(), ",")
+	for i, col := range cols {
+		cols[i] = strings.TrimSpace(col)
+	}
+	return cols, nil
+}
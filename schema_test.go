@@ -0,0 +1,72 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package trackml
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sbinet/go-trackml/internal/testevent"
+)
+
+func TestCheckSchema(t *testing.T) {
+	dir, err := ioutil.TempDir("", "trkml-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	write := func(evtid, name, header string) {
+		fname := filepath.Join(dir, evtid+"-"+name+".csv")
+		err := ioutil.WriteFile(fname, []byte(header+"\n"), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, name := range []string{"hits", "cells", "particles", "truth"} {
+		write("event000000001", name, strings.Join(Schemas[name], ","))
+	}
+	write("event000000002", "hits", strings.Join(Schemas["hits"], ","))
+	write("event000000002", "cells", "hit_id,ch0,ch1")
+	write("event000000003", "hits", strings.Join(Schemas["hits"], ","))
+
+	for _, tc := range []struct {
+		evtid string
+		err   string
+	}{
+		{"event000000001", ""},
+		{"event000000002", "invalid cells columns [hit_id,ch0,ch1], want [hit_id,ch0,ch1,value]"},
+		{"event000000003", "missing cells file"},
+	} {
+		t.Run(tc.evtid, func(t *testing.T) {
+			err := CheckSchema(dir, tc.evtid)
+			switch {
+			case err == nil && tc.err != "":
+				t.Fatalf("expected an error (%s)", tc.err)
+			case err != nil && tc.err == "":
+				t.Fatalf("unexpected error: %v", err)
+			case err != nil && !strings.Contains(err.Error(), tc.err):
+				t.Fatalf("invalid error:\ngot = %v\nwant= %v", err, tc.err)
+			}
+		})
+	}
+}
+
+func TestSyntheticSchema(t *testing.T) {
+	evt := testevent.New(10, 5)
+	for name, cols := range Schemas {
+		rows, ok := evt[name]
+		if !ok {
+			t.Fatalf("missing synthetic %s file", name)
+		}
+		if got, want := rows[0], strings.Join(cols, ","); got != want {
+			t.Fatalf("invalid synthetic %s header:\ngot = %s\nwant= %s", name, got, want)
+		}
+	}
+}
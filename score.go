@@ -9,10 +9,19 @@ import (
 	"sort"
 )
 
+// Unassigned is the track ID given to hits that have not been attached
+// to any reconstructed track.
+const Unassigned = -1
+
 // Score computes the TrackML event score for a single event.
+//
+// Hits labeled as Unassigned are each considered as a single-hit track.
+// Blacklisted hits are ignored and the weights of the other hits are
+// renormalized.
 func Score(evt Event, trkIDs []int) float64 {
 	sum := 0.0
-	trks := analyzeTracks(evt.Mcs, evt.Hits, trkIDs)
+	mcs, hits, trkIDs := scoredHits(evt, trkIDs)
+	trks := analyzeTracks(mcs, hits, singletons(trkIDs))
 	for _, trk := range trks {
 		var (
 			majHits   = float64(trk.MajHits)
@@ -30,6 +39,122 @@ func Score(evt Event, trkIDs []int) float64 {
 	return sum
 }
 
+// TrackMatch associates a reconstructed track with the particle contributing
+// the majority of its hits.
+type TrackMatch struct {
+	TrackID int     // ID of the reconstructed track
+	Hits    int     // number of hits of the reconstructed track
+	PID     int     // ID of the majority particle
+	PHits   int     // number of hits of the majority particle
+	MajHits int     // number of hits of the track belonging to the majority particle
+	Weight  float64 // normalized weight of the hits of the track belonging to the majority particle
+}
+
+// Good returns whether the track and its majority particle share more than
+// half of their hits, i.e. whether the track contributes to the score.
+func (m TrackMatch) Good() bool {
+	return 2*m.MajHits > m.Hits && 2*m.MajHits > m.PHits
+}
+
+// MatchTracks returns the majority particle of each reconstructed track.
+// Unassigned hits are ignored, as are blacklisted hits.
+func MatchTracks(evt Event, trkIDs []int) []TrackMatch {
+	max := Unassigned
+	for _, tid := range trkIDs {
+		if tid > max {
+			max = tid
+		}
+	}
+
+	mcs, hits, trkIDs := scoredHits(evt, trkIDs)
+	if len(hits) == 0 {
+		return nil
+	}
+	trks := analyzeTracks(mcs, hits, singletons(trkIDs))
+	ms := make([]TrackMatch, 0, len(trks))
+	for _, trk := range trks {
+		if trk.ID == Unassigned || trk.ID > max {
+			continue
+		}
+		ms = append(ms, TrackMatch{
+			TrackID: trk.ID,
+			Hits:    trk.Hits,
+			PID:     trk.MajPID,
+			PHits:   trk.MajPHits,
+			MajHits: trk.MajHits,
+			Weight:  trk.MajWeight,
+		})
+	}
+	return ms
+}
+
+// ParticleIDs returns the particle ID of each hit of the event, matching the
+// Monte-Carlo truth to the hits by hit ID.
+// Noise hits and hits without truth have a particle ID of 0.
+func (evt *Event) ParticleIDs() []int {
+	pid := make(map[int]int, len(evt.Mcs))
+	for _, mc := range evt.Mcs {
+		pid[mc.HitID] = mc.PID
+	}
+	pids := make([]int, len(evt.Hits))
+	for i, hit := range evt.Hits {
+		pids[i] = pid[hit.HitID]
+	}
+	return pids
+}
+
+// scoredHits returns the non-blacklisted hits of the event, with their truth
+// and track IDs, in the order of evt.Hits.
+//
+// Truth is matched to hits by hit ID: hits without truth are considered as
+// noise, with a zero weight.
+// The returned slices are always copies, so the event is left untouched.
+func scoredHits(evt Event, trkIDs []int) ([]Truth, []Hit, []int) {
+	var (
+		ids  = evt.blacklisted()
+		idx  = make(map[int]int, len(evt.Mcs))
+		mcs  = make([]Truth, 0, len(evt.Hits))
+		hits = make([]Hit, 0, len(evt.Hits))
+		tids = make([]int, 0, len(evt.Hits))
+	)
+	for i, mc := range evt.Mcs {
+		idx[mc.HitID] = i
+	}
+	for i, hit := range evt.Hits {
+		if ids[hit.HitID] {
+			continue
+		}
+		mc := Truth{HitID: hit.HitID}
+		if j, ok := idx[hit.HitID]; ok {
+			mc = evt.Mcs[j]
+		}
+		mcs = append(mcs, mc)
+		hits = append(hits, hit)
+		tids = append(tids, trkIDs[i])
+	}
+	return mcs, hits, tids
+}
+
+// singletons returns a copy of trkIDs where each Unassigned hit
+// has been given its own, unique, track ID.
+func singletons(trkIDs []int) []int {
+	max := -1
+	for _, tid := range trkIDs {
+		if tid > max {
+			max = tid
+		}
+	}
+	ids := make([]int, len(trkIDs))
+	for i, tid := range trkIDs {
+		if tid == Unassigned {
+			max++
+			tid = max
+		}
+		ids[i] = tid
+	}
+	return ids
+}
+
 func extractMcHitIDs(mcs []Truth) []int {
 	ids := make([]int, len(mcs))
 	for i, mc := range mcs {
@@ -100,7 +225,14 @@ func (nt ntuple) display(pid int) {
 	}
 }
 
+// analyzeTracks returns the reconstructed tracks of the hits, with their
+// majority particle.
+// The provided slices are left untouched.
 func analyzeTracks(mcs []Truth, hits []Hit, trkIDs []int) []recTrack {
+	mcs = append([]Truth(nil), mcs...)
+	hits = append([]Hit(nil), hits...)
+	trkIDs = append([]int(nil), trkIDs...)
+
 	// compute the true number of hits for each particle id
 	pids := make(map[int]int, len(mcs))
 	totalWeight := 0.0
@@ -119,7 +251,6 @@ func analyzeTracks(mcs []Truth, hits []Hit, trkIDs []int) []recTrack {
 	invTotWeight := 1 / totalWeight
 
 	sort.Sort(byTrackAndPID{nt})
-	defer sort.Sort(byHitID{nt})
 
 	//	log.Printf("")
 	//	log.Printf("===========================")
@@ -253,15 +384,3 @@ func (evt byTrackAndPID) Less(i, j int) bool {
 	}
 	return false
 }
-
-type byHitID struct {
-	nt ntuple
-}
-
-func (evt byHitID) Len() int { return len(evt.nt.hits) }
-func (evt byHitID) Swap(i, j int) {
-	evt.nt.hits[i], evt.nt.hits[j] = evt.nt.hits[j], evt.nt.hits[i]
-	evt.nt.mcs[i], evt.nt.mcs[j] = evt.nt.mcs[j], evt.nt.mcs[i]
-	evt.nt.trkIDs[i], evt.nt.trkIDs[j] = evt.nt.trkIDs[j], evt.nt.trkIDs[i]
-}
-func (evt byHitID) Less(i, j int) bool { return evt.nt.hits[i].HitID < evt.nt.hits[j].HitID }
@@ -0,0 +1,165 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package trackml
+
+import (
+	"math"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func newTestEvent() Event {
+	// 2 particles with 4 hits each, and 5 noise hits.
+	var evt Event
+	for i := 0; i < 13; i++ {
+		var (
+			pid    int
+			weight float64
+		)
+		switch {
+		case i < 4:
+			pid, weight = 1, 0.125
+		case i < 8:
+			pid, weight = 2, 0.125
+		}
+		evt.Hits = append(evt.Hits, Hit{HitID: i + 1})
+		evt.Mcs = append(evt.Mcs, Truth{HitID: i + 1, PID: pid, Weight: weight})
+	}
+	return evt
+}
+
+func TestScoreUnassigned(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		noise  int
+		labels []int
+		want   float64
+	}{
+		{
+			name:  "unassigned",
+			noise: Unassigned,
+			want:  1,
+		},
+		{
+			// noise hits merged with the first track spoil it.
+			name:  "merged",
+			noise: 0,
+			want:  0.5,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			evt := newTestEvent()
+			labels := []int{0, 0, 0, 0, 1, 1, 1, 1}
+			for len(labels) < len(evt.Hits) {
+				labels = append(labels, tc.noise)
+			}
+			orig := append([]int(nil), labels...)
+
+			got := Score(evt, labels)
+			if math.Abs(got-tc.want) > 1e-12 {
+				t.Fatalf("invalid score: got=%v, want=%v", got, tc.want)
+			}
+			if !reflect.DeepEqual(labels, orig) {
+				t.Fatalf("labels modified\ngot = %v\nwant= %v", labels, orig)
+			}
+		})
+	}
+}
+
+func TestScoreUnsorted(t *testing.T) {
+	evt := Event{
+		Hits: []Hit{{HitID: 3}, {HitID: 1}, {HitID: 2}},
+		Mcs: []Truth{
+			{HitID: 3, PID: 7, Weight: 0.5},
+			{HitID: 1, PID: 5, Weight: 0.25},
+			{HitID: 2, PID: 5, Weight: 0.25},
+		},
+	}
+	labels := []int{9, 4, 4}
+
+	for i := 0; i < 2; i++ {
+		got := Score(evt, labels)
+		if math.Abs(got-1) > 1e-12 {
+			t.Fatalf("invalid score (call #%d): got=%v, want=1", i, got)
+		}
+		ids := extractHitIDs(evt.Hits)
+		if want := []int{3, 1, 2}; !reflect.DeepEqual(ids, want) {
+			t.Fatalf("hits modified\ngot = %v\nwant= %v", ids, want)
+		}
+	}
+}
+
+func TestScoreConcurrent(t *testing.T) {
+	evt := newTestEvent()
+	evt.Hits[0], evt.Hits[12] = evt.Hits[12], evt.Hits[0]
+	evt.Mcs[0], evt.Mcs[12] = evt.Mcs[12], evt.Mcs[0]
+	labels := []int{Unassigned, 0, 0, 0, 1, 1, 1, 1, 2, 2, 2, 2, 0}
+
+	want := Score(evt, labels)
+	var grp sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		grp.Add(1)
+		go func() {
+			defer grp.Done()
+			if got := Score(evt, labels); got != want {
+				t.Errorf("invalid score: got=%v, want=%v", got, want)
+			}
+			MatchTracks(evt, labels)
+		}()
+	}
+	grp.Wait()
+}
+
+func TestSingletons(t *testing.T) {
+	ids := []int{Unassigned, 0, 2, Unassigned, 1}
+	got := singletons(ids)
+	want := []int{3, 0, 2, 4, 1}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("singletons error\ngot = %v\nwant= %v", got, want)
+	}
+}
+
+func TestMatchTracks(t *testing.T) {
+	evt := newTestEvent()
+	labels := []int{0, 0, 0, 1, 1, 1, 1, 1, 2, 2, Unassigned, Unassigned, Unassigned}
+
+	got := MatchTracks(evt, labels)
+	want := []TrackMatch{
+		{TrackID: 0, Hits: 3, PID: 1, PHits: 4, MajHits: 3, Weight: 0.375},
+		{TrackID: 1, Hits: 5, PID: 2, PHits: 4, MajHits: 4, Weight: 0.5},
+		{TrackID: 2, Hits: 2, PID: 0, PHits: 5, MajHits: 2, Weight: 0},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("invalid matches\ngot = %+v\nwant= %+v", got, want)
+	}
+	for i, good := range []bool{true, true, false} {
+		if got[i].Good() != good {
+			t.Fatalf("invalid good flag for track %d: got=%v, want=%v", i, got[i].Good(), good)
+		}
+	}
+
+	if got := MatchTracks(Event{}, nil); got != nil {
+		t.Fatalf("invalid matches for empty event: %+v", got)
+	}
+	labels = make([]int, len(evt.Hits))
+	for i := range labels {
+		labels[i] = Unassigned
+	}
+	if got := MatchTracks(evt, labels); len(got) != 0 {
+		t.Fatalf("invalid matches for unassigned hits: %+v", got)
+	}
+}
+
+func TestParticleIDs(t *testing.T) {
+	evt := Event{
+		Hits: []Hit{{HitID: 3}, {HitID: 1}, {HitID: 2}},
+		Mcs:  []Truth{{HitID: 1, PID: 10}, {HitID: 4, PID: 20}, {HitID: 3, PID: 30}},
+	}
+	want := []int{30, 10, 0}
+	if got := evt.ParticleIDs(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("invalid particle IDs: got=%v, want=%v", got, want)
+	}
+}
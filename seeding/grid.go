@@ -0,0 +1,100 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package seeding
+
+import (
+	"math"
+)
+
+// grid bins the hits of a layer in phi and z.
+type grid struct {
+	nphi int
+	nz   int
+	zmin float64
+	zmax float64
+	bins [][]int // hit indices, per (phi,z) bin
+}
+
+func newGrid(idx []int, pts []point, nphi, nz int) *grid {
+	g := &grid{
+		nphi: nphi,
+		nz:   nz,
+		zmin: math.Inf(+1),
+		zmax: math.Inf(-1),
+		bins: make([][]int, nphi*nz),
+	}
+	for _, i := range idx {
+		g.zmin = math.Min(g.zmin, pts[i].z)
+		g.zmax = math.Max(g.zmax, pts[i].z)
+	}
+	for _, i := range idx {
+		ibin := g.index(g.iphi(pts[i].phi), g.iz(pts[i].z))
+		g.bins[ibin] = append(g.bins[ibin], i)
+	}
+	return g
+}
+
+func (g *grid) index(iphi, iz int) int {
+	return iphi*g.nz + iz
+}
+
+func (g *grid) iphi(phi float64) int {
+	i := int(math.Floor((phi + math.Pi) / (2 * math.Pi) * float64(g.nphi)))
+	i %= g.nphi
+	if i < 0 {
+		i += g.nphi
+	}
+	return i
+}
+
+func (g *grid) iz(z float64) int {
+	if g.zmax <= g.zmin {
+		return 0
+	}
+	i := int((z - g.zmin) / (g.zmax - g.zmin) * float64(g.nz))
+	switch {
+	case i < 0:
+		return 0
+	case i >= g.nz:
+		return g.nz - 1
+	}
+	return i
+}
+
+// query calls fct with the indices of the hits within the phi window
+// [phi-dphi, phi+dphi] and the z window [zlo, zhi].
+func (g *grid) query(phi, dphi, zlo, zhi float64, fct func(i int)) {
+	if zhi < g.zmin || zlo > g.zmax {
+		return
+	}
+	var (
+		ilo  = g.iz(zlo)
+		ihi  = g.iz(zhi)
+		nphi = int(math.Ceil(dphi/(2*math.Pi)*float64(g.nphi))) + 1
+		i0   = g.iphi(phi)
+	)
+	if 2*nphi+1 >= g.nphi {
+		// the window covers all the phi bins: visit each of them once.
+		for iphi := 0; iphi < g.nphi; iphi++ {
+			g.visit(iphi, ilo, ihi, fct)
+		}
+		return
+	}
+	for d := -nphi; d <= nphi; d++ {
+		iphi := (i0 + d) % g.nphi
+		if iphi < 0 {
+			iphi += g.nphi
+		}
+		g.visit(iphi, ilo, ihi, fct)
+	}
+}
+
+func (g *grid) visit(iphi, ilo, ihi int, fct func(i int)) {
+	for iz := ilo; iz <= ihi; iz++ {
+		for _, i := range g.bins[g.index(iphi, iz)] {
+			fct(i)
+		}
+	}
+}
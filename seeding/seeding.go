@@ -0,0 +1,318 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package seeding builds track seeds, doublets and triplets of hits on
+// consecutive detector layers.
+package seeding // import "github.com/sbinet/go-trackml/seeding"
+
+import (
+	"math"
+
+	"github.com/sbinet/go-trackml"
+	"github.com/sbinet/go-trackml/layers"
+)
+
+// Seed is a track seed.
+type Seed struct {
+	Hits      []int   // indices of the hits of the seed, from the innermost to the outermost one
+	Curvature float64 // signed curvature of the seed in the transverse plane (1/mm)
+}
+
+// Seeder builds seeds from the hits of an event.
+//
+// Doublets are made of hits on layers connected in the layer graph.
+// Triplets are made of two doublets sharing their middle hit.
+type Seeder struct {
+	Graph   *layers.Graph // layer connectivity graph
+	MinFreq float64       // minimum frequency of the layer transitions to consider
+
+	PhiBins int // number of phi bins of the per-layer hit grids
+	ZBins   int // number of z bins of the per-layer hit grids
+
+	MaxDeltaPhi  float64 // maximum azimuthal angle between the hits of a doublet (rad)
+	MaxZ0        float64 // maximum |z| of the doublet line at the beam axis (mm)
+	MaxCurvature float64 // maximum |curvature| of a seed in the transverse plane (1/mm)
+	MaxDeltaCot  float64 // maximum difference of cot(theta) between the two doublets of a triplet
+	MaxD0        float64 // maximum transverse impact parameter of a triplet (mm)
+}
+
+// New returns a seeder using the provided layer graph, with default windows
+// suited for particles above 0.5 GeV/c in a 2 Tesla field.
+func New(g *layers.Graph) *Seeder {
+	return &Seeder{
+		Graph:        g,
+		MinFreq:      0.01,
+		PhiBins:      128,
+		ZBins:        32,
+		MaxDeltaPhi:  0.15,
+		MaxZ0:        200,
+		MaxCurvature: 1.5e-3,
+		MaxDeltaCot:  0.2,
+		MaxD0:        10,
+	}
+}
+
+type point struct {
+	x, y, z float64
+	r, phi  float64
+}
+
+type layerHits struct {
+	idx  []int
+	rmin float64
+	rmax float64
+	grid *grid
+}
+
+// Doublets returns the doublets made of the provided hits.
+func (s *Seeder) Doublets(hits []trackml.Hit) []Seed {
+	var (
+		pts = make([]point, len(hits))
+		lay = make(map[layers.Layer]*layerHits)
+	)
+	for i, hit := range hits {
+		pts[i] = point{
+			x:   hit.X,
+			y:   hit.Y,
+			z:   hit.Z,
+			r:   math.Hypot(hit.X, hit.Y),
+			phi: math.Atan2(hit.Y, hit.X),
+		}
+		l := layers.HitLayer(hit)
+		lh, ok := lay[l]
+		if !ok {
+			lh = &layerHits{rmin: math.Inf(+1), rmax: math.Inf(-1)}
+			lay[l] = lh
+		}
+		lh.idx = append(lh.idx, i)
+		lh.rmin = math.Min(lh.rmin, pts[i].r)
+		lh.rmax = math.Max(lh.rmax, pts[i].r)
+	}
+
+	var seeds []Seed
+	for _, tr := range s.Graph.Transitions() {
+		if tr.Freq < s.MinFreq {
+			continue
+		}
+		inner, ok := lay[tr.From]
+		if !ok {
+			continue
+		}
+		outer, ok := lay[tr.To]
+		if !ok {
+			continue
+		}
+		if outer.grid == nil {
+			outer.grid = newGrid(outer.idx, pts, s.PhiBins, s.ZBins)
+		}
+
+		for _, i := range inner.idx {
+			p1 := pts[i]
+			if p1.r == 0 {
+				continue
+			}
+			zlo, zhi := s.zWindow(p1, outer.rmin, outer.rmax)
+			outer.grid.query(p1.phi, s.MaxDeltaPhi, zlo, zhi, func(j int) {
+				p2 := pts[j]
+				if math.Abs(math.Remainder(p2.phi-p1.phi, 2*math.Pi)) > s.MaxDeltaPhi {
+					return
+				}
+				if p2.r == p1.r {
+					return
+				}
+				z0 := p1.z - p1.r*(p2.z-p1.z)/(p2.r-p1.r)
+				if math.Abs(z0) > s.MaxZ0 {
+					return
+				}
+				k := curvature(point{}, p1, p2)
+				if math.Abs(k) > s.MaxCurvature {
+					return
+				}
+				seeds = append(seeds, Seed{Hits: []int{i, j}, Curvature: k})
+			})
+		}
+	}
+	return seeds
+}
+
+// zWindow returns the z window of the hits on a layer spanning [rmin, rmax]
+// in radius, compatible with a straight line from the beam axis, within
+// MaxZ0, through p.
+func (s *Seeder) zWindow(p point, rmin, rmax float64) (zlo, zhi float64) {
+	zlo = math.Inf(+1)
+	zhi = math.Inf(-1)
+	for _, z0 := range []float64{-s.MaxZ0, +s.MaxZ0} {
+		for _, r := range []float64{rmin, rmax} {
+			z := p.z + (p.z-z0)*(r-p.r)/p.r
+			zlo = math.Min(zlo, z)
+			zhi = math.Max(zhi, z)
+		}
+	}
+	return zlo, zhi
+}
+
+// Triplets returns the triplets made of the provided hits.
+func (s *Seeder) Triplets(hits []trackml.Hit) []Seed {
+	var (
+		doublets = s.Doublets(hits)
+		byInner  = make(map[int][]int, len(doublets))
+		pts      = make([]point, len(hits))
+		seeds    []Seed
+	)
+	for i, hit := range hits {
+		pts[i] = point{x: hit.X, y: hit.Y, z: hit.Z}
+	}
+	for i, d := range doublets {
+		byInner[d.Hits[0]] = append(byInner[d.Hits[0]], i)
+	}
+
+	for _, d1 := range doublets {
+		var (
+			a   = pts[d1.Hits[0]]
+			b   = pts[d1.Hits[1]]
+			cot = (b.z - a.z) / math.Hypot(b.x-a.x, b.y-a.y)
+		)
+		for _, id2 := range byInner[d1.Hits[1]] {
+			d2 := doublets[id2]
+			c := pts[d2.Hits[1]]
+
+			k := curvature(a, b, c)
+			if math.Abs(k) > s.MaxCurvature {
+				continue
+			}
+			cot2 := (c.z - b.z) / math.Hypot(c.x-b.x, c.y-b.y)
+			if math.Abs(cot2-cot) > s.MaxDeltaCot {
+				continue
+			}
+			if math.Abs(impact(a, b, c)) > s.MaxD0 {
+				continue
+			}
+			seeds = append(seeds, Seed{
+				Hits:      []int{d1.Hits[0], d1.Hits[1], d2.Hits[1]},
+				Curvature: k,
+			})
+		}
+	}
+	return seeds
+}
+
+// curvature returns the signed curvature of the circle through a, b and c,
+// in the transverse plane.
+// The curvature is positive for counter-clockwise a->b->c.
+func curvature(a, b, c point) float64 {
+	var (
+		abx = b.x - a.x
+		aby = b.y - a.y
+		acx = c.x - a.x
+		acy = c.y - a.y
+		bcx = c.x - b.x
+		bcy = c.y - b.y
+	)
+	den := math.Hypot(abx, aby) * math.Hypot(acx, acy) * math.Hypot(bcx, bcy)
+	if den == 0 {
+		return math.Inf(+1)
+	}
+	return 2 * (abx*acy - aby*acx) / den
+}
+
+// impact returns the distance of closest approach to the origin of the circle
+// through a, b and c, in the transverse plane.
+func impact(a, b, c point) float64 {
+	var (
+		bx = b.x - a.x
+		by = b.y - a.y
+		cx = c.x - a.x
+		cy = c.y - a.y
+		d  = 2 * (bx*cy - by*cx)
+	)
+	if d == 0 {
+		// straight line through a and c.
+		n := math.Hypot(cx, cy)
+		if n == 0 {
+			return 0
+		}
+		return (a.x*cy - a.y*cx) / n
+	}
+	var (
+		b2 = bx*bx + by*by
+		c2 = cx*cx + cy*cy
+		ux = (cy*b2 - by*c2) / d
+		uy = (bx*c2 - cx*b2) / d
+		r  = math.Hypot(ux, uy)
+	)
+	return math.Hypot(ux+a.x, uy+a.y) - r
+}
+
+// Stats summarizes the efficiency and purity of a set of seeds.
+type Stats struct {
+	Seeds     int // number of seeds
+	True      int // number of seeds with all their hits from the same particle
+	Particles int // number of reconstructable particles
+	Found     int // number of reconstructable particles with at least one true seed
+}
+
+// Efficiency returns the fraction of reconstructable particles with at least
+// one true seed.
+func (st Stats) Efficiency() float64 {
+	if st.Particles == 0 {
+		return 0
+	}
+	return float64(st.Found) / float64(st.Particles)
+}
+
+// Purity returns the fraction of true seeds.
+func (st Stats) Purity() float64 {
+	if st.Seeds == 0 {
+		return 0
+	}
+	return float64(st.True) / float64(st.Seeds)
+}
+
+// Evaluate computes the efficiency and purity of the seeds built from the hits
+// of the provided event.
+// Particles with at least minHits hits are considered reconstructable.
+// Truth is matched to the hits by hit ID.
+func Evaluate(evt trackml.Event, seeds []Seed, minHits int) Stats {
+	var (
+		pids  = evt.ParticleIDs()
+		nhits = make(map[int]int)
+	)
+	for _, pid := range pids {
+		if pid == 0 {
+			continue
+		}
+		nhits[pid]++
+	}
+
+	st := Stats{Seeds: len(seeds)}
+	for _, n := range nhits {
+		if n >= minHits {
+			st.Particles++
+		}
+	}
+
+	found := make(map[int]struct{})
+	for _, seed := range seeds {
+		pid := pids[seed.Hits[0]]
+		if pid == 0 {
+			continue
+		}
+		ok := true
+		for _, i := range seed.Hits[1:] {
+			if pids[i] != pid {
+				ok = false
+				break
+			}
+		}
+		if !ok {
+			continue
+		}
+		st.True++
+		if nhits[pid] >= minHits {
+			found[pid] = struct{}{}
+		}
+	}
+	st.Found = len(found)
+	return st
+}
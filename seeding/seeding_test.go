@@ -0,0 +1,137 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package seeding
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/sbinet/go-trackml"
+	"github.com/sbinet/go-trackml/internal/testhelix"
+	"github.com/sbinet/go-trackml/layers"
+)
+
+func TestTriplets(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1234))
+	evt := testhelix.New(rnd, 50, 200, 0)
+
+	g := layers.New()
+	g.Add(evt)
+
+	s := New(g)
+	doublets := s.Doublets(evt.Hits)
+	triplets := s.Triplets(evt.Hits)
+
+	for _, tc := range []struct {
+		name  string
+		seeds []Seed
+		nhits int
+	}{
+		{"doublets", doublets, 2},
+		{"triplets", triplets, 3},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, seed := range tc.seeds {
+				if len(seed.Hits) != tc.nhits {
+					t.Fatalf("invalid number of hits: %v", seed.Hits)
+				}
+			}
+			st := Evaluate(evt, tc.seeds, 3)
+			if st.Particles != 50 {
+				t.Fatalf("invalid number of particles: got=%d, want=%d", st.Particles, 50)
+			}
+			if st.Efficiency() != 1 {
+				t.Fatalf("invalid efficiency: %v (%+v)", st.Efficiency(), st)
+			}
+			if st.True < 50*(len(testhelix.Radii)+1-tc.nhits) {
+				t.Fatalf("missing true seeds: %+v", st)
+			}
+		})
+	}
+
+	if pd, pt := Evaluate(evt, doublets, 3).Purity(), Evaluate(evt, triplets, 3).Purity(); pt <= pd {
+		t.Fatalf("triplets purity (%v) not better than doublets purity (%v)", pt, pd)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	// truth is matched to hits by hit ID.
+	evt := trackml.Event{
+		Hits: []trackml.Hit{{HitID: 1}, {HitID: 2}, {HitID: 3}, {HitID: 4}, {HitID: 5}, {HitID: 6}},
+		Mcs: []trackml.Truth{
+			{HitID: 6, PID: 0}, {HitID: 5, PID: 2}, {HitID: 4, PID: 2},
+			{HitID: 3, PID: 1}, {HitID: 2, PID: 1}, {HitID: 1, PID: 1},
+		},
+	}
+	seeds := []Seed{
+		{Hits: []int{0, 1}},
+		{Hits: []int{1, 2}},
+		{Hits: []int{2, 3}},
+		{Hits: []int{3, 4}},
+		{Hits: []int{4, 5}},
+	}
+	st := Evaluate(evt, seeds, 3)
+	want := Stats{Seeds: 5, True: 3, Particles: 1, Found: 1}
+	if st != want {
+		t.Fatalf("invalid stats\ngot = %+v\nwant= %+v", st, want)
+	}
+	if got, want := st.Purity(), 0.6; got != want {
+		t.Fatalf("invalid purity: got=%v, want=%v", got, want)
+	}
+}
+
+func TestGridQuery(t *testing.T) {
+	rnd := rand.New(rand.NewSource(42))
+	var (
+		pts = make([]point, 1000)
+		idx = make([]int, len(pts))
+	)
+	for i := range pts {
+		pts[i] = point{
+			phi: math.Pi * (2*rnd.Float64() - 1),
+			z:   1000 * (rnd.Float64() - 0.5),
+		}
+		idx[i] = i
+	}
+
+	for _, tc := range []struct {
+		nphi, nz int
+		phi      float64
+		dphi     float64
+		zlo, zhi float64
+	}{
+		{16, 8, 0, 0.2, -100, 100},
+		{16, 8, math.Pi - 0.05, 0.2, -600, 0},
+		{16, 8, -math.Pi + 0.05, 0.2, 0, 600},
+		{16, 8, 1, 4, -100, 100},
+		{15, 8, 1, 4, -100, 100},
+		{1, 1, 1, 0.1, -10, 10},
+	} {
+		g := newGrid(idx, pts, tc.nphi, tc.nz)
+		var got []int
+		g.query(tc.phi, tc.dphi, tc.zlo, tc.zhi, func(i int) {
+			got = append(got, i)
+		})
+		sort.Ints(got)
+		for k := 1; k < len(got); k++ {
+			if got[k] == got[k-1] {
+				t.Fatalf("hit %d visited twice", got[k])
+			}
+		}
+		found := make(map[int]bool, len(got))
+		for _, i := range got {
+			found[i] = true
+		}
+		for i, p := range pts {
+			in := math.Abs(math.Remainder(p.phi-tc.phi, 2*math.Pi)) <= tc.dphi &&
+				tc.zlo <= p.z && p.z <= tc.zhi
+			if in && !found[i] {
+				t.Fatalf("%+v: hit %d (%+v) not visited", tc, i, p)
+			}
+		}
+	}
+}
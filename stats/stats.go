@@ -0,0 +1,164 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package stats computes statistics about the events of a dataset.
+package stats
+
+import (
+	"math"
+	"sort"
+
+	"github.com/sbinet/go-trackml"
+	"github.com/sbinet/go-trackml/layers"
+)
+
+// Summary describes the distribution of a quantity.
+type Summary struct {
+	N    int     `json:"n"`
+	Mean float64 `json:"mean"`
+	Min  float64 `json:"min"`
+	Max  float64 `json:"max"`
+}
+
+// Add adds a value to the distribution.
+func (s *Summary) Add(v float64) {
+	s.Merge(Summary{N: 1, Mean: v, Min: v, Max: v})
+}
+
+// Merge merges the distribution o into s.
+func (s *Summary) Merge(o Summary) {
+	switch {
+	case o.N == 0:
+		return
+	case s.N == 0:
+		*s = o
+		return
+	}
+	n := s.N + o.N
+	s.Mean += float64(o.N) / float64(n) * (o.Mean - s.Mean)
+	s.Min = math.Min(s.Min, o.Min)
+	s.Max = math.Max(s.Max, o.Max)
+	s.N = n
+}
+
+// Layer holds the number of hits in a detector layer.
+type Layer struct {
+	Volume int `json:"volume_id"`
+	Layer  int `json:"layer_id"`
+	Hits   int `json:"hits"`
+}
+
+// Event holds the statistics of an event.
+type Event struct {
+	ID              int     `json:"event_id"`
+	Hits            int     `json:"hits"`
+	Cells           int     `json:"cells"`
+	Particles       int     `json:"particles"`
+	Noise           int     `json:"noise_hits"` // number of hits not associated with any particle
+	NoiseFraction   float64 `json:"noise_fraction"`
+	Weight          float64 `json:"weight_sum"` // sum of the weights of the hits
+	HitsPerParticle Summary `json:"hits_per_particle"`
+	CellsPerHit     Summary `json:"cells_per_hit"`
+	Layers          []Layer `json:"layers"`
+	Schema          string  `json:"schema_error,omitempty"` // mismatch of the CSV headers, if any
+}
+
+// NewEvent returns the statistics of the provided event.
+func NewEvent(evt trackml.Event) Event {
+	st := Event{
+		ID:        evt.ID,
+		Hits:      len(evt.Hits),
+		Cells:     len(evt.Cells),
+		Particles: len(evt.Ps),
+	}
+
+	nhits := make(map[int]int)
+	for _, mc := range evt.Mcs {
+		st.Weight += mc.Weight
+		if mc.PID == 0 {
+			st.Noise++
+			continue
+		}
+		nhits[mc.PID]++
+	}
+	for _, n := range nhits {
+		st.HitsPerParticle.Add(float64(n))
+	}
+	if st.Hits > 0 {
+		st.NoiseFraction = float64(st.Noise) / float64(st.Hits)
+	}
+
+	ncells := make(map[int]int, len(evt.Hits))
+	for _, cell := range evt.Cells {
+		ncells[cell.HitID]++
+	}
+	for _, hit := range evt.Hits {
+		st.CellsPerHit.Add(float64(ncells[hit.HitID]))
+	}
+
+	counts := make(map[layers.Layer]int)
+	for _, hit := range evt.Hits {
+		counts[layers.Layer{Volume: hit.VolumeID, Layer: hit.LayerID}]++
+	}
+	st.Layers = sortedLayers(counts)
+
+	return st
+}
+
+// Dataset holds the statistics of many events.
+type Dataset struct {
+	Events          int     `json:"events"`
+	Hits            Summary `json:"hits"`       // number of hits per event
+	Cells           Summary `json:"cells"`      // number of cells per event
+	Particles       Summary `json:"particles"`  // number of particles per event
+	Weight          Summary `json:"weight_sum"` // sum of the weights of the hits per event
+	TotalHits       int     `json:"total_hits"`
+	Noise           int     `json:"noise_hits"`
+	NoiseFraction   float64 `json:"noise_fraction"`
+	HitsPerParticle Summary `json:"hits_per_particle"`
+	CellsPerHit     Summary `json:"cells_per_hit"`
+	Layers          []Layer `json:"layers"`
+	SchemaErrors    int     `json:"schema_errors"` // number of events with a mismatch of the CSV headers
+}
+
+// Add adds the statistics of an event to the dataset.
+func (ds *Dataset) Add(st Event) {
+	ds.Events++
+	ds.Hits.Add(float64(st.Hits))
+	ds.Cells.Add(float64(st.Cells))
+	ds.Particles.Add(float64(st.Particles))
+	ds.Weight.Add(st.Weight)
+	ds.TotalHits += st.Hits
+	ds.Noise += st.Noise
+	if ds.TotalHits > 0 {
+		ds.NoiseFraction = float64(ds.Noise) / float64(ds.TotalHits)
+	}
+	ds.HitsPerParticle.Merge(st.HitsPerParticle)
+	ds.CellsPerHit.Merge(st.CellsPerHit)
+	if st.Schema != "" {
+		ds.SchemaErrors++
+	}
+
+	counts := make(map[layers.Layer]int)
+	for _, ls := range [][]Layer{ds.Layers, st.Layers} {
+		for _, l := range ls {
+			counts[layers.Layer{Volume: l.Volume, Layer: l.Layer}] += l.Hits
+		}
+	}
+	ds.Layers = sortedLayers(counts)
+}
+
+func sortedLayers(counts map[layers.Layer]int) []Layer {
+	o := make([]Layer, 0, len(counts))
+	for l, n := range counts {
+		o = append(o, Layer{Volume: l.Volume, Layer: l.Layer, Hits: n})
+	}
+	sort.Slice(o, func(i, j int) bool {
+		if o[i].Volume != o[j].Volume {
+			return o[i].Volume < o[j].Volume
+		}
+		return o[i].Layer < o[j].Layer
+	})
+	return o
+}
@@ -0,0 +1,90 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stats
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sbinet/go-trackml"
+)
+
+func TestSummary(t *testing.T) {
+	var s, a, b Summary
+	for i, v := range []float64{3, 1, 4, 1, 5, 9, 2, 6} {
+		s.Add(v)
+		if i < 3 {
+			a.Add(v)
+		} else {
+			b.Add(v)
+		}
+	}
+	want := Summary{N: 8, Mean: 31. / 8, Min: 1, Max: 9}
+	if s != want {
+		t.Fatalf("invalid summary:\ngot = %+v\nwant= %+v", s, want)
+	}
+	a.Merge(b)
+	if a != want {
+		t.Fatalf("invalid merged summary:\ngot = %+v\nwant= %+v", a, want)
+	}
+}
+
+func TestEvent(t *testing.T) {
+	evt := trackml.Event{
+		ID: 42,
+		Hits: []trackml.Hit{
+			{HitID: 1, VolumeID: 8, LayerID: 2},
+			{HitID: 2, VolumeID: 8, LayerID: 4},
+			{HitID: 3, VolumeID: 8, LayerID: 2},
+			{HitID: 4, VolumeID: 7, LayerID: 2},
+		},
+		Cells: []trackml.Cell{
+			{HitID: 1}, {HitID: 1}, {HitID: 2}, {HitID: 3}, {HitID: 3}, {HitID: 3},
+		},
+		Ps: []trackml.Particle{{ID: 10}, {ID: 20}},
+		Mcs: []trackml.Truth{
+			{HitID: 1, PID: 10, Weight: 0.25},
+			{HitID: 2, PID: 10, Weight: 0.25},
+			{HitID: 3, PID: 20, Weight: 0.5},
+			{HitID: 4, PID: 0},
+		},
+	}
+
+	got := NewEvent(evt)
+	want := Event{
+		ID:              42,
+		Hits:            4,
+		Cells:           6,
+		Particles:       2,
+		Noise:           1,
+		NoiseFraction:   0.25,
+		Weight:          1,
+		HitsPerParticle: Summary{N: 2, Mean: 1.5, Min: 1, Max: 2},
+		CellsPerHit:     Summary{N: 4, Mean: 1.5, Min: 0, Max: 3},
+		Layers: []Layer{
+			{Volume: 7, Layer: 2, Hits: 1},
+			{Volume: 8, Layer: 2, Hits: 2},
+			{Volume: 8, Layer: 4, Hits: 1},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("invalid event statistics:\ngot = %+v\nwant= %+v", got, want)
+	}
+
+	var ds Dataset
+	ds.Add(got)
+	got.Schema = "invalid"
+	ds.Add(got)
+
+	if ds.Events != 2 || ds.TotalHits != 8 || ds.Noise != 2 || ds.SchemaErrors != 1 {
+		t.Fatalf("invalid dataset statistics: %+v", ds)
+	}
+	if got, want := ds.NoiseFraction, 0.25; got != want {
+		t.Fatalf("invalid noise fraction: got=%v, want=%v", got, want)
+	}
+	if got, want := ds.Layers[1], (Layer{Volume: 8, Layer: 2, Hits: 4}); got != want {
+		t.Fatalf("invalid layer:\ngot = %+v\nwant= %+v", got, want)
+	}
+}
@@ -7,8 +7,10 @@ package trackml
 import (
 	"compress/gzip"
 	"encoding/csv"
+	"io"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/pkg/errors"
 )
@@ -20,14 +22,25 @@ type Submission struct {
 	csv *csv.Writer
 }
 
+// NewSubmission creates a submission.csv.gz file in the current directory.
 func NewSubmission() (*Submission, error) {
-	f, err := os.Create("submission.csv.gz")
+	return CreateSubmission("submission.csv.gz")
+}
+
+// CreateSubmission creates the named submission file.
+// Files with a ".gz" extension are compressed on the fly.
+func CreateSubmission(fname string) (*Submission, error) {
+	f, err := os.Create(fname)
 	if err != nil {
 		return nil, err
 	}
 	sub := &Submission{f: f}
-	sub.gw = gzip.NewWriter(f)
-	sub.csv = csv.NewWriter(sub.gw)
+	var w io.Writer = f
+	if strings.HasSuffix(fname, ".gz") {
+		sub.gw = gzip.NewWriter(f)
+		w = sub.gw
+	}
+	sub.csv = csv.NewWriter(w)
 
 	err = sub.csv.Write([]string{"event_id", "hit_id", "track_id"})
 	if err != nil {
@@ -40,7 +53,10 @@ func NewSubmission() (*Submission, error) {
 func (sub *Submission) Close() error {
 	sub.csv.Flush()
 	err1 := sub.csv.Error()
-	err2 := sub.gw.Close()
+	var err2 error
+	if sub.gw != nil {
+		err2 = sub.gw.Close()
+	}
 	err3 := sub.f.Close()
 	if err1 != nil {
 		return err1
@@ -54,12 +70,15 @@ func (sub *Submission) Close() error {
 	return nil
 }
 
+// Append writes the track IDs of all the hits of the provided event.
+// Hits labeled as Unassigned are written out as single-hit tracks.
 func (sub *Submission) Append(evt Event, trkIDs []int) error {
 	defer sub.csv.Flush()
 
 	if len(evt.Hits) != len(trkIDs) {
 		return errors.Errorf("length mismatch")
 	}
+	trkIDs = singletons(trkIDs)
 	var (
 		rec   [3]string
 		evtid = strconv.Itoa(evt.ID)
@@ -76,3 +95,79 @@ func (sub *Submission) Append(evt Event, trkIDs []int) error {
 
 	return sub.csv.Error()
 }
+
+// Predictions holds the track IDs of the hits of many events, indexed by
+// event ID and hit ID.
+type Predictions map[int]map[int]int
+
+// Labels returns the track IDs of the hits of the provided event.
+// Hits without a prediction are Unassigned.
+func (p Predictions) Labels(evt Event) []int {
+	var (
+		ids    = make([]int, len(evt.Hits))
+		trkIDs = p[evt.ID]
+	)
+	for i, hit := range evt.Hits {
+		tid, ok := trkIDs[hit.HitID]
+		if !ok {
+			tid = Unassigned
+		}
+		ids[i] = tid
+	}
+	return ids
+}
+
+// ReadSubmission reads the predictions of a submission file.
+// Files with a ".gz" extension are decompressed on the fly.
+func ReadSubmission(fname string) (Predictions, error) {
+	f, err := os.Open(fname)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not open submission file")
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(fname, ".gz") {
+		gr, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not open gzip submission file")
+		}
+		defer gr.Close()
+		r = gr
+	}
+
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = 3
+	cr.ReuseRecord = true
+
+	_, err = cr.Read() // skip header
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not read submission header")
+	}
+
+	preds := make(Predictions)
+	for line := 2; ; line++ {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not read submission row")
+		}
+		var ids [3]int
+		for i, v := range rec {
+			ids[i], err = strconv.Atoi(v)
+			if err != nil {
+				return nil, errors.Wrapf(err, "could not parse submission line %d", line)
+			}
+		}
+		evt, ok := preds[ids[0]]
+		if !ok {
+			evt = make(map[int]int)
+			preds[ids[0]] = evt
+		}
+		evt[ids[1]] = ids[2]
+	}
+
+	return preds, nil
+}
@@ -0,0 +1,65 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package trackml
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadSubmission(t *testing.T) {
+	dir, err := ioutil.TempDir("", "trkml-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	evt := newTestEvent()
+	evt.ID = 42
+	labels := []int{0, 0, 0, 0, 1, 1, 1, 1, Unassigned, Unassigned, 2, 2, Unassigned}
+
+	for _, name := range []string{"submission.csv", "submission.csv.gz"} {
+		t.Run(name, func(t *testing.T) {
+			fname := filepath.Join(dir, name)
+			sub, err := CreateSubmission(fname)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = sub.Append(evt, labels)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = sub.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			preds, err := ReadSubmission(fname)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(preds) != 1 {
+				t.Fatalf("invalid number of events: got=%d, want=1", len(preds))
+			}
+
+			got := preds.Labels(evt)
+			want := singletons(labels)
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("invalid labels:\ngot = %v\nwant= %v", got, want)
+			}
+
+			other := evt
+			other.ID = 43
+			for i, tid := range preds.Labels(other) {
+				if tid != Unassigned {
+					t.Fatalf("hit %d: got=%d, want=%d", i, tid, Unassigned)
+				}
+			}
+		})
+	}
+}
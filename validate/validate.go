@@ -0,0 +1,242 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package validate checks the integrity of the events of a dataset.
+package validate
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sbinet/go-trackml"
+)
+
+// Check identifies an integrity check.
+type Check string
+
+const (
+	MissingFile    Check = "missing-file"   // a file of the event is missing
+	InvalidHeader  Check = "invalid-header" // the CSV columns of a file are not the expected ones
+	Unreadable     Check = "unreadable"     // a file could not be read or decoded
+	DuplicateHitID Check = "duplicate-hit"  // a hit ID appears more than once
+	HitIDRange     Check = "hit-id-range"   // hit IDs are not contiguous from 1
+	UnknownHitID   Check = "unknown-hit"    // a cell or truth row refers to a missing hit
+	WeightSum      Check = "weight-sum"     // truth weights do not sum to 1
+	ParticleNHits  Check = "particle-nhits" // the number of hits of a particle disagrees with truth
+)
+
+// Error is a failed integrity check of an event.
+//
+// Failures of a check on many rows of a file are aggregated into a single
+// Error, described by its first occurrence.
+type Error struct {
+	Event string `json:"event"`
+	File  string `json:"file,omitempty"` // hits, cells, particles or truth
+	Check Check  `json:"check"`
+	Count int    `json:"count"` // number of failures
+	Msg   string `json:"msg"`
+}
+
+func (e Error) Error() string {
+	msg := e.Msg
+	if e.Count > 1 {
+		msg = fmt.Sprintf("%s (and %d more)", msg, e.Count-1)
+	}
+	if e.File == "" {
+		return fmt.Sprintf("%s: %s: %s", e.Event, e.Check, msg)
+	}
+	return fmt.Sprintf("%s-%s.csv: %s: %s", e.Event, e.File, e.Check, msg)
+}
+
+// Report holds the failed checks of the events of a dataset.
+type Report struct {
+	Events int     `json:"events"` // number of checked events
+	Errors []Error `json:"errors"`
+}
+
+// Err returns the report as an error if any check failed, nil otherwise.
+func (r *Report) Err() error {
+	if len(r.Errors) == 0 {
+		return nil
+	}
+	return r
+}
+
+// Counts returns the number of failures of each check.
+func (r *Report) Counts() map[Check]int {
+	counts := make(map[Check]int)
+	for _, e := range r.Errors {
+		counts[e.Check] += e.Count
+	}
+	return counts
+}
+
+func (r *Report) Error() string {
+	var (
+		counts = r.Counts()
+		checks = make([]string, 0, len(counts))
+		evts   = make(map[string]bool)
+	)
+	for c, n := range counts {
+		checks = append(checks, fmt.Sprintf("%s=%d", c, n))
+	}
+	sort.Strings(checks)
+	for _, e := range r.Errors {
+		evts[e.Event] = true
+	}
+	return fmt.Sprintf(
+		"validate: %d/%d invalid events (%s)",
+		len(evts), r.Events, strings.Join(checks, ", "),
+	)
+}
+
+// Validator checks the integrity of events.
+type Validator struct {
+	WeightTol float64 // tolerance on the sum of truth weights
+}
+
+// New returns a new validator with a tolerance of 1e-4 on the sum of truth
+// weights.
+func New() *Validator {
+	return &Validator{WeightTol: 1e-4}
+}
+
+// Dataset checks the events of a dataset, a directory, zip or tar file, as
+// selected by beg and end as for trackml.NewDataset.
+func (v *Validator) Dataset(path string, beg, end int) (*Report, error) {
+	ds, err := trackml.NewDataset(path, beg, end, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer ds.Close()
+
+	var r Report
+	for _, name := range ds.Names() {
+		r.Errors = append(r.Errors, v.Event(ds.Path(), filepath.Base(name))...)
+		r.Events++
+	}
+	return &r, nil
+}
+
+// Event checks the named event of a dataset.
+func (v *Validator) Event(path, evtid string) []Error {
+	var errs errList
+
+	hdrs, err := trackml.ReadHeaders(path, evtid)
+	if err != nil {
+		errs.add(evtid, "", Unreadable, "%v", err)
+		return errs
+	}
+
+	valid := true
+	for _, name := range []string{"hits", "cells", "particles", "truth"} {
+		cols, ok := hdrs[name]
+		if !ok {
+			errs.add(evtid, name, MissingFile, "no such file")
+			valid = false
+			continue
+		}
+		want := trackml.Schemas[name]
+		if strings.Join(cols, ",") != strings.Join(want, ",") {
+			errs.add(evtid, name, InvalidHeader,
+				"columns [%s], want [%s]",
+				strings.Join(cols, ","), strings.Join(want, ","),
+			)
+			valid = false
+		}
+	}
+	if !valid {
+		return errs
+	}
+
+	evt, err := trackml.ReadMcEvent(path, evtid)
+	if err != nil {
+		errs.add(evtid, "", Unreadable, "%v", err)
+		return errs
+	}
+
+	v.checkEvent(&errs, evtid, evt)
+	return errs
+}
+
+// checkEvent checks the content of an event.
+func (v *Validator) checkEvent(errs *errList, evtid string, evt trackml.Event) {
+	var (
+		hits = make(map[int]bool, len(evt.Hits))
+		min  = math.MaxInt64
+		max  = math.MinInt64
+	)
+	for i, hit := range evt.Hits {
+		id := hit.HitID
+		if hits[id] {
+			errs.add(evtid, "hits", DuplicateHitID, "row %d: hit %d", i+1, id)
+			continue
+		}
+		hits[id] = true
+		if id < min {
+			min = id
+		}
+		if id > max {
+			max = id
+		}
+	}
+	if len(hits) > 0 && (min != 1 || max != len(hits)) {
+		errs.add(evtid, "hits", HitIDRange,
+			"%d hit IDs in [%d, %d], want [1, %d]", len(hits), min, max, len(hits),
+		)
+	}
+
+	for i, cell := range evt.Cells {
+		if !hits[cell.HitID] {
+			errs.add(evtid, "cells", UnknownHitID, "row %d: hit %d", i+1, cell.HitID)
+		}
+	}
+
+	var (
+		sum   = 0.0
+		nhits = make(map[int]int)
+	)
+	for i, mc := range evt.Mcs {
+		if !hits[mc.HitID] {
+			errs.add(evtid, "truth", UnknownHitID, "row %d: hit %d", i+1, mc.HitID)
+		}
+		sum += mc.Weight
+		if mc.PID != 0 {
+			nhits[mc.PID]++
+		}
+	}
+	if math.Abs(sum-1) > v.WeightTol {
+		errs.add(evtid, "truth", WeightSum, "sum of weights is %v", sum)
+	}
+
+	for i, p := range evt.Ps {
+		if n := nhits[p.ID]; n != p.NHits {
+			errs.add(evtid, "particles", ParticleNHits,
+				"row %d: particle %d has %d hits, truth has %d", i+1, p.ID, p.NHits, n,
+			)
+		}
+	}
+}
+
+// errList aggregates the failures of the checks of an event.
+type errList []Error
+
+func (errs *errList) add(evtid, file string, check Check, format string, args ...interface{}) {
+	for i, e := range *errs {
+		if e.File == file && e.Check == check {
+			(*errs)[i].Count++
+			return
+		}
+	}
+	*errs = append(*errs, Error{
+		Event: evtid,
+		File:  file,
+		Check: check,
+		Count: 1,
+		Msg:   fmt.Sprintf(format, args...),
+	})
+}
@@ -0,0 +1,116 @@
+// Copyright 2018 The go-trackml Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package validate
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/sbinet/go-trackml/internal/testevent"
+)
+
+func TestValidator(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		alter func(evt testevent.Event)
+		want  []Error
+	}{
+		{
+			name: "valid",
+		},
+		{
+			name:  "missing-file",
+			alter: func(files testevent.Event) { delete(files, "truth") },
+			want: []Error{
+				{File: "truth", Check: MissingFile, Count: 1, Msg: "no such file"},
+			},
+		},
+		{
+			name:  "missing-hits",
+			alter: func(files testevent.Event) { delete(files, "hits") },
+			want: []Error{
+				{File: "hits", Check: MissingFile, Count: 1, Msg: "no such file"},
+			},
+		},
+		{
+			name: "invalid-header",
+			alter: func(files testevent.Event) {
+				files["cells"][0] = "hit_id,ch0,ch1,val"
+			},
+			want: []Error{
+				{
+					File: "cells", Check: InvalidHeader, Count: 1,
+					Msg: "columns [hit_id,ch0,ch1,val], want [hit_id,ch0,ch1,value]",
+				},
+			},
+		},
+		{
+			name: "hit-ids",
+			alter: func(files testevent.Event) {
+				files["hits"][2] = "1,0,0,0,8,2,1"
+				files["hits"][4] = "5,0,0,0,8,4,1"
+			},
+			want: []Error{
+				{File: "hits", Check: DuplicateHitID, Count: 1, Msg: "row 2: hit 1"},
+				{File: "hits", Check: HitIDRange, Count: 1, Msg: "3 hit IDs in [1, 5], want [1, 3]"},
+				{File: "cells", Check: UnknownHitID, Count: 4, Msg: "row 3: hit 2"},
+				{File: "truth", Check: UnknownHitID, Count: 2, Msg: "row 2: hit 2"},
+			},
+		},
+		{
+			name: "weights-and-nhits",
+			alter: func(files testevent.Event) {
+				files["truth"][4] = "4,0,0,0,0,0,0,0,0.20"
+			},
+			want: []Error{
+				{File: "truth", Check: WeightSum, Count: 1, Msg: "sum of weights is 0.95"},
+				{File: "particles", Check: ParticleNHits, Count: 1, Msg: "row 2: particle 4503599627370497 has 2 hits, truth has 1"},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "trkml-validate-")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+
+			const evtid = "event000000001"
+			// 4 hits from 2 particles.
+			evt := testevent.New(4, 2)
+			if tc.alter != nil {
+				tc.alter(evt)
+			}
+			_, err = evt.Write(dir, evtid)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rep, err := New().Dataset(dir, 0, -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rep.Events != 1 {
+				t.Fatalf("invalid number of events: got=%d, want=1", rep.Events)
+			}
+
+			for i := range tc.want {
+				tc.want[i].Event = evtid
+			}
+			if !reflect.DeepEqual(rep.Errors, tc.want) {
+				t.Fatalf("invalid errors:\ngot = %+v\nwant= %+v", rep.Errors, tc.want)
+			}
+
+			switch err := rep.Err(); {
+			case len(tc.want) == 0 && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case len(tc.want) != 0 && err == nil:
+				t.Fatalf("expected an error")
+			}
+		})
+	}
+}